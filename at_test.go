@@ -0,0 +1,50 @@
+package logs
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+type countMarshal struct{ n *int }
+
+func (c countMarshal) MarshalJSON() ([]byte, error) {
+	*c.n++
+	return []byte(`1`), nil
+}
+
+// TestAt verifies the level-first chain encodes fields only when the level is enabled.
+func TestAt(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	calls := 0
+	l.DebugWith().Any("req", countMarshal{&calls}).Str("k", "v").Msg("hidden")
+	if calls != 0 || buf.Len() != 0 {
+		t.Fatalf("disabled chain encoded fields: calls=%d out=%q", calls, buf.String())
+	}
+	l.Trace("api").WarnWith().Any("req", countMarshal{&calls}).Msgf("code=%d", 7)
+	l.At(LevelError).Int("n", 1).Msg("boom")
+	l.At(LevelMute).Msg("never")
+	out := buf.String()
+	if calls != 1 || !strings.Contains(out, "level=WRN trace=api req=1 msg=code=7\n") ||
+		!strings.Contains(out, "level=ERR n=1 msg=boom\n") || strings.Contains(out, "never") {
+		t.Fatalf("At output mismatch (calls=%d):\n%s", calls, out)
+	}
+	buf.Reset()
+	l.With().Msg("plain") // With chains default to INF
+	if !strings.Contains(buf.String(), "level=INF msg=plain") {
+		t.Fatalf("With().Msg mismatch: %s", buf.String())
+	}
+}
+
+// TestAtDisabledAllocs verifies a disabled chain does not allocate.
+func TestAtDisabledAllocs(t *testing.T) {
+	l := New(io.Discard, WithHijack(false))
+	var v any = struct{ A, B string }{"a", "b"} // boxed once: the interface conversion is the caller's cost
+	if n := testing.AllocsPerRun(100, func() {
+		l.DebugWith().Any("v", v).Str("k", "v").Int("n", 1).Msg("x")
+	}); n != 0 {
+		t.Fatalf("disabled chain allocates %.0f times", n)
+	}
+}
@@ -12,7 +12,7 @@ func (s *fielder) Str(key, val string) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutStringQuote(textenc.PutKey(*s.attr, key), val)
+	*s.attr = putStrField(s.cfg, *s.attr, key, val)
 	return s
 }
 
@@ -22,11 +22,11 @@ func (s *fielder) Stringer(key string, val fmt.Stringer) *fielder {
 		return s
 	}
 	if val != nil {
-		*s.attr = textenc.PutStringQuote(textenc.PutKey(*s.attr, key), val.String())
+		*s.attr = putTextField(s.cfg, *s.attr, key, val, s.cfg.enc.PutStringer)
 		return s
 	}
 
-	*s.attr = textenc.PutAny(textenc.PutKey(*s.attr, key), nil)
+	*s.attr = putAnyField(s.cfg, *s.attr, key, nil)
 	return s
 }
 
@@ -35,7 +35,7 @@ func (s *fielder) Bytes(key string, val []byte) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutBytesQuote(textenc.PutKey(*s.attr, key), val)
+	*s.attr = putField(s.cfg, *s.attr, key, val, s.cfg.enc.PutBytesQuote)
 	return s
 }
 
@@ -44,11 +44,7 @@ func (s *fielder) Err(err error) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	if err == nil {
-		*s.attr = textenc.PutNil(textenc.PutKey(*s.attr, errorFieldName))
-	} else {
-		*s.attr = textenc.PutStringQuote(textenc.PutKey(*s.attr, errorFieldName), err.Error())
-	}
+	*s.attr = putTextField(s.cfg, *s.attr, errorFieldName, err, s.cfg.enc.PutError)
 	return s
 }
 
@@ -61,7 +57,7 @@ func (s *fielder) IfErr(err error) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutStringQuote(textenc.PutKey(*s.attr, errorFieldName), err.Error())
+	*s.attr = putTextField(s.cfg, *s.attr, errorFieldName, err, s.cfg.enc.PutError)
 	return s
 }
 
@@ -76,7 +72,7 @@ func (s *fielder) Bool(key string, b bool) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutBool(textenc.PutKey(*s.attr, key), b)
+	*s.attr = putField(s.cfg, *s.attr, key, b, textenc.PutBool)
 	return s
 }
 
@@ -85,7 +81,7 @@ func (s *fielder) Int(key string, i int) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutInt(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putField(s.cfg, *s.attr, key, i, textenc.PutInt)
 	return s
 }
 
@@ -94,7 +90,7 @@ func (s *fielder) Int8(key string, i int8) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutInt8(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putField(s.cfg, *s.attr, key, i, textenc.PutInt8)
 	return s
 }
 
@@ -103,7 +99,7 @@ func (s *fielder) Int16(key string, i int16) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutInt16(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putField(s.cfg, *s.attr, key, i, textenc.PutInt16)
 	return s
 }
 
@@ -112,7 +108,7 @@ func (s *fielder) Int32(key string, i int32) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutInt32(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putField(s.cfg, *s.attr, key, i, textenc.PutInt32)
 	return s
 }
 
@@ -121,7 +117,7 @@ func (s *fielder) Int64(key string, i int64) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutInt64(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putField(s.cfg, *s.attr, key, i, textenc.PutInt64)
 	return s
 }
 
@@ -130,7 +126,7 @@ func (s *fielder) Uint(key string, i uint) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutUint(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putField(s.cfg, *s.attr, key, i, textenc.PutUint)
 	return s
 }
 
@@ -139,7 +135,7 @@ func (s *fielder) Uint8(key string, i uint8) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutUint8(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putField(s.cfg, *s.attr, key, i, textenc.PutUint8)
 	return s
 }
 
@@ -148,7 +144,7 @@ func (s *fielder) Uint16(key string, i uint16) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutUint16(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putField(s.cfg, *s.attr, key, i, textenc.PutUint16)
 	return s
 }
 
@@ -157,7 +153,7 @@ func (s *fielder) Uint32(key string, i uint32) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutUint32(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putField(s.cfg, *s.attr, key, i, textenc.PutUint32)
 	return s
 }
 
@@ -166,7 +162,7 @@ func (s *fielder) Uint64(key string, i uint64) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutUint64(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putField(s.cfg, *s.attr, key, i, textenc.PutUint64)
 	return s
 }
 
@@ -175,7 +171,7 @@ func (s *fielder) Float32(key string, f float32) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutFloat32(textenc.PutKey(*s.attr, key), f)
+	*s.attr = putField(s.cfg, *s.attr, key, f, textenc.PutFloat32)
 	return s
 }
 
@@ -184,7 +180,7 @@ func (s *fielder) Float64(key string, f float64) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutFloat64(textenc.PutKey(*s.attr, key), f)
+	*s.attr = putField(s.cfg, *s.attr, key, f, textenc.PutFloat64)
 	return s
 }
 
@@ -193,7 +189,7 @@ func (s *fielder) Time(key string, t time.Time) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutTime(textenc.PutKey(*s.attr, key), t)
+	*s.attr = putField(s.cfg, *s.attr, key, t, textenc.PutTime)
 	return s
 }
 
@@ -202,7 +198,7 @@ func (s *fielder) Dur(key string, d time.Duration) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutDuration(textenc.PutKey(*s.attr, key), d)
+	*s.attr = putField(s.cfg, *s.attr, key, d, s.cfg.enc.PutDuration)
 	return s
 }
 
@@ -211,7 +207,7 @@ func (s *fielder) Any(key string, i any) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = textenc.PutAny(textenc.PutKey(*s.attr, key), i)
+	*s.attr = putAnyField(s.cfg, *s.attr, key, i)
 	return s
 }
 
@@ -220,6 +216,6 @@ func (s *fielder) Raw(key string, b []byte) *fielder {
 	if s.attr == nil {
 		return s
 	}
-	*s.attr = append(textenc.PutKey(*s.attr, key), b...)
+	*s.attr = putField(s.cfg, *s.attr, key, b, s.cfg.enc.PutRaw)
 	return s
 }
@@ -0,0 +1,265 @@
+package logs
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/zxysilent/logs/internal/textenc"
+)
+
+const (
+	fileFieldName = "file"
+	lineFieldName = "line"
+	funcFieldName = "func"
+
+	maxCachedFrames = 1 << 16
+	maxHelperDepth  = 16 // frames searched for a non-helper caller
+)
+
+// CallerPath selects how file paths in caller and stack fields are rendered.
+type CallerPath uint8
+
+const (
+	// CallerPathSep trims the path at the rightmost WithSep separator (default): /conn.go
+	CallerPathSep CallerPath = iota
+	// CallerPathFull keeps the path recorded by the compiler: /home/u/app/internal/db/conn.go
+	CallerPathFull
+	// CallerPathModule renders paths relative to the main module root
+	// (internal/db/conn.go); other packages use their import path (net/http/server.go).
+	CallerPathModule
+)
+
+// WithCallerPath sets how caller and stack file paths are rendered.
+func WithCallerPath(p CallerPath) Option {
+	return func(c *config) { c.callerPath = p }
+}
+
+// WithCallerFunc sets whether caller information includes the function
+// name, written as func=pkg.(*T).Method.
+func WithCallerFunc(b bool) Option {
+	return func(c *config) { c.callerFunc = b }
+}
+
+// WithCallerFields sets whether caller information is written as separate
+// file=... line=... fields instead of caller=file:line.
+func WithCallerFields(b bool) Option {
+	return func(c *config) { c.callerFields = b }
+}
+
+// frame is one resolved call site. A program counter inside inlined code
+// resolves to several frames, innermost first, linked through outer.
+type frame struct {
+	file  string // path as recorded by the compiler
+	rel   string // module-relative path
+	name  string // fully qualified function name
+	fn    string // function name without the import path directory
+	line  int
+	outer *frame // frame the function was inlined into, if any
+}
+
+var unknownFrame = frame{file: "###", rel: "###", name: "###", fn: "###"}
+
+// frames caches resolved program counters, so a lookup never allocates once
+// a call site has been seen. It holds at most maxCachedFrames entries.
+var (
+	frames   = make(map[uintptr]*frame)
+	framesMu sync.RWMutex
+)
+
+// lookupFrame resolves pc, a return address as reported by runtime.Callers.
+func lookupFrame(pc uintptr) *frame {
+	framesMu.RLock()
+	f, ok := frames[pc]
+	framesMu.RUnlock()
+	if ok {
+		return f
+	}
+	f = resolveFrame(pc)
+	framesMu.Lock()
+	if len(frames) < maxCachedFrames {
+		frames[pc] = f
+	}
+	framesMu.Unlock()
+	return f
+}
+
+func resolveFrame(pc uintptr) *frame {
+	var head, tail *frame
+	it := runtime.CallersFrames([]uintptr{pc})
+	for {
+		rf, more := it.Next()
+		if rf.Function != "" {
+			f := &frame{name: rf.Function, file: rf.File, line: rf.Line}
+			f.fn = f.name[strings.LastIndexByte(f.name, '/')+1:]
+			f.rel = modulePath(f.name, f.file)
+			if head == nil {
+				head = f
+			} else {
+				tail.outer = f
+			}
+			tail = f
+		}
+		if !more {
+			break
+		}
+	}
+	if head == nil {
+		f := unknownFrame
+		return &f
+	}
+	return head
+}
+
+// Functions marked by Helper. The set is small and rarely written, so it is
+// copied on write and read without locking.
+var (
+	helpers   atomic.Pointer[map[string]struct{}]
+	helpersMu sync.Mutex
+)
+
+// Helper marks the calling function as a logging helper, like
+// testing.T.Helper: caller fields of records it logs report the first
+// function outside registered helpers. Call it at the top of the helper;
+// repeated calls are cheap.
+func Helper() {
+	var pcs [1]uintptr
+	if runtime.Callers(2, pcs[:]) < 1 {
+		return
+	}
+	name := lookupFrame(pcs[0]).name
+	if isHelper(helpers.Load(), name) {
+		return
+	}
+	helpersMu.Lock()
+	defer helpersMu.Unlock()
+	m := make(map[string]struct{})
+	if old := helpers.Load(); old != nil {
+		for k := range *old {
+			m[k] = struct{}{}
+		}
+	}
+	m[name] = struct{}{}
+	helpers.Store(&m)
+}
+
+func isHelper(hs *map[string]struct{}, name string) bool {
+	if hs == nil {
+		return false
+	}
+	_, ok := (*hs)[name]
+	return ok
+}
+
+// callerFrame returns the frame at skip, counted as runtime.Callers would
+// from callerFrame's caller, walking past registered helpers. When every
+// frame searched is a helper, the frame at skip is returned.
+func callerFrame(skip int) *frame {
+	var pcs [4]uintptr
+	hs := helpers.Load()
+	if hs == nil {
+		if runtime.Callers(skip+1, pcs[:1]) < 1 {
+			return &unknownFrame
+		}
+		return lookupFrame(pcs[0])
+	}
+	first := &unknownFrame
+	for depth := 0; depth < maxHelperDepth; depth += len(pcs) {
+		n := runtime.Callers(skip+1+depth, pcs[:])
+		for i, pc := range pcs[:n] {
+			f := lookupFrame(pc)
+			if depth == 0 && i == 0 {
+				first = f
+			}
+			for ; f != nil; f = f.outer {
+				if !isHelper(hs, f.name) {
+					return f
+				}
+			}
+		}
+		if n < len(pcs) {
+			break
+		}
+	}
+	return first
+}
+
+// Main module and main package paths, read once from the build info.
+var (
+	buildOnce sync.Once
+	buildMod  string
+	buildMain string
+)
+
+// modulePath renders file relative to the main module root. The directory
+// comes from the import path of the function's package, so it does not
+// depend on where the module was checked out or whether -trimpath was used.
+func modulePath(name, file string) string {
+	buildOnce.Do(func() {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			buildMod, buildMain = bi.Main.Path, bi.Path
+		}
+	})
+	base := file[strings.LastIndexByte(file, '/')+1:]
+	pkg := funcPackage(name)
+	if pkg == "main" && buildMain != "" {
+		pkg = buildMain
+	}
+	if buildMod != "" && strings.HasPrefix(pkg, buildMod) {
+		dir := pkg[len(buildMod):]
+		if dir == "" {
+			return base
+		}
+		if dir[0] == '/' {
+			return dir[1:] + "/" + base
+		}
+	}
+	return pkg + "/" + base
+}
+
+// funcPackage returns the import path of the package a function belongs to.
+func funcPackage(name string) string {
+	slash := strings.LastIndexByte(name, '/')
+	if dot := strings.IndexByte(name[slash+1:], '.'); dot >= 0 {
+		return name[:slash+1+dot]
+	}
+	return name
+}
+
+// framePath renders the file of f according to the configured CallerPath.
+func (c *config) framePath(f *frame) string {
+	switch c.callerPath {
+	case CallerPathFull:
+		return f.file
+	case CallerPathModule:
+		return f.rel
+	}
+	if slash := lastSep(f.file, c.sep); slash >= 0 {
+		return f.file[slash:]
+	}
+	return f.file
+}
+
+// putCaller writes "caller=file:line" (or file/line fields, plus func when
+// enabled) into buf. skip is the full frame count passed to runtime.Callers
+// (computed by the caller); frames of functions marked by Helper are passed
+// over. Resolved call sites are cached, so this does not allocate.
+func (c *config) putCaller(buf *buffer, skip int) {
+	f := callerFrame(skip)
+	file := c.framePath(f)
+	if c.callerFields {
+		*buf = c.enc.PutString(textenc.PutKeyRaw(*buf, fileFieldName), file)
+		*buf = textenc.PutInt(textenc.PutKeyRaw(*buf, lineFieldName), f.line)
+	} else {
+		var tmp [128]byte
+		v := append(append(tmp[:0], file...), ':')
+		v = strconv.AppendInt(v, int64(f.line), 10)
+		*buf = c.enc.PutBytesQuote(textenc.PutKeyRaw(*buf, callerFieldName), v)
+	}
+	if c.callerFunc {
+		*buf = c.enc.PutString(textenc.PutKeyRaw(*buf, funcFieldName), f.fn)
+	}
+}
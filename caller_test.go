@@ -0,0 +1,165 @@
+package logs
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// callerLine returns the line of the statement that called it.
+func callerLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// TestCallerFormats verifies path modes, func names and separate fields.
+func TestCallerFormats(t *testing.T) {
+	_, abs, _, _ := runtime.Caller(0)
+	abs = filepath.ToSlash(abs)
+	cases := []struct {
+		opts []Option
+		want string
+	}{
+		{nil, " caller=/caller_test.go:%d "},
+		{[]Option{WithCallerPath(CallerPathFull)}, " caller=" + abs + ":%d "},
+		{[]Option{WithCallerPath(CallerPathModule)}, " caller=caller_test.go:%d "},
+		{[]Option{WithCallerFunc(true)}, " caller=/caller_test.go:%d func=logs.TestCallerFormats "},
+		{[]Option{WithCallerFields(true), WithCallerFunc(true)}, " file=/caller_test.go line=%d func=logs.TestCallerFormats "},
+	}
+	for i, c := range cases {
+		var buf bytes.Buffer
+		l := New(&buf, append(c.opts, WithHijack(false), WithCaller(true))...)
+		l.Info("x")
+		line := callerLine() - 1
+		want := strings.Replace(c.want, "%d", strconv.Itoa(line), 1)
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("case %d: want %q in %s", i, want, buf.String())
+		}
+	}
+}
+
+// TestCallerStackPath verifies stacks follow the caller path mode.
+func TestCallerStackPath(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithCallerPath(CallerPathModule), WithStackDepth(1))
+	l.With().Stack().Info("x")
+	frames := stackOf(t, buf.String())
+	if len(frames) != 1 || !strings.HasPrefix(frames[0], "github.com/zxysilent/logs.TestCallerStackPath caller_test.go:") {
+		t.Fatalf("stack frame = %q", frames)
+	}
+}
+
+// TestModulePath verifies module-relative paths for the main module and other packages.
+func TestModulePath(t *testing.T) {
+	cases := []struct{ name, file, want string }{
+		{"github.com/zxysilent/logs.New", "/src/logs/logger.go", "logger.go"},
+		{"github.com/zxysilent/logs/internal/file.(*Writer).Write", "/src/logs/internal/file/file.go", "internal/file/file.go"},
+		{"github.com/zxysilent/logsx.F", "/src/logsx/x.go", "github.com/zxysilent/logsx/x.go"},
+		{"net/http.(*conn).serve", "/go/src/net/http/server.go", "net/http/server.go"},
+		{"fmt.Sprint.func1", "/go/src/fmt/print.go", "fmt/print.go"},
+	}
+	for _, c := range cases {
+		if got := modulePath(c.name, c.file); got != c.want {
+			t.Errorf("modulePath(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+// TestCallerQuoted verifies caller paths with spaces are quoted in both modes.
+func TestCallerQuoted(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		var buf bytes.Buffer
+		l := New(&buf, WithHijack(false), WithCaller(true), WithCallerPath(CallerPathFull), WithStrictLogfmt(strict))
+		line := 0
+		for i := 0; i < 2; i++ {
+			if i == 1 {
+				// pretend the call site below lives in a directory with a space
+				framesMu.Lock()
+				for pc, f := range frames {
+					if f.line == line && strings.HasSuffix(f.file, "/caller_test.go") {
+						g := *f
+						g.file = "/my src/caller_test.go"
+						frames[pc] = &g
+					}
+				}
+				framesMu.Unlock()
+				buf.Reset()
+			}
+			l.Info("x")
+			line = callerLine() - 1
+		}
+		want := ` caller="/my src/caller_test.go:` + strconv.Itoa(line) + `" `
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("strict=%v: want %q in %s", strict, want, buf.String())
+		}
+	}
+}
+
+// TestCallerAllocs verifies cached caller lookups do not allocate.
+func TestCallerAllocs(t *testing.T) {
+	l := New(io.Discard, WithHijack(false), WithCaller(true), WithCallerFunc(true), WithCallerPath(CallerPathModule))
+	if n := testing.AllocsPerRun(100, func() { l.Info("x") }); n != 0 {
+		t.Fatalf("caller allocates %.0f times", n)
+	}
+}
+
+// logVia logs through a function marked by Helper.
+func logVia(l *Logger) {
+	Helper()
+	l.Info("via")
+}
+
+// logViaNested is a helper calling another helper.
+//
+//go:noinline
+func logViaNested(l *Logger) {
+	Helper()
+	logVia(l)
+}
+
+// TestHelper verifies caller fields walk past functions marked by Helper.
+func TestHelper(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithCaller(true), WithCallerFunc(true))
+	logVia(l)
+	a := callerLine() - 1
+	logViaNested(l)
+	b := callerLine() - 1
+	for _, line := range []int{a, b} {
+		want := " caller=/caller_test.go:" + strconv.Itoa(line) + " func=logs.TestHelper "
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("want %q in\n%s", want, buf.String())
+		}
+	}
+	l = New(io.Discard, WithHijack(false), WithCaller(true))
+	if n := testing.AllocsPerRun(100, func() { logViaNested(l) }); n != 0 {
+		t.Fatalf("helper walk allocates %.0f times", n)
+	}
+}
+
+// skipOne logs on behalf of its caller with fielder.Skip.
+//
+//go:noinline
+func skipOne(l *Logger) {
+	l.With().Skip(1).Info("s")
+}
+
+// TestFielderSkip verifies Skip only affects its own record.
+func TestFielderSkip(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithCaller(true))
+	skipOne(l)
+	a := callerLine() - 1
+	l.With().Info("plain")
+	b := callerLine() - 1
+	out := buf.String()
+	for _, line := range []int{a, b} {
+		if want := " caller=/caller_test.go:" + strconv.Itoa(line) + " "; !strings.Contains(out, want) {
+			t.Fatalf("want %q in\n%s", want, out)
+		}
+	}
+}
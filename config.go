@@ -2,8 +2,11 @@ package logs
 
 import (
 	"io"
+	"sync/atomic"
+	"time"
 
 	"github.com/zxysilent/logs/internal/file"
+	"github.com/zxysilent/logs/internal/textenc"
 )
 
 // config is the root configuration shared by Logger instances.
@@ -23,6 +26,50 @@ type config struct {
 	skip   int
 	caller bool
 	hijack bool
+	clock  Clock // nil uses time.Now
+	enc    textenc.Encoder
+
+	callerPath   CallerPath // how caller and stack file paths are rendered
+	callerFunc   bool       // add the function name to caller information
+	callerFields bool       // write separate file/line fields instead of caller=file:line
+
+	stackLevel Level // records at or above it get a stack field (LevelMute: never)
+	stackDepth int   // maximum frames per stack field
+
+	onError  ErrorHandler  // called for every failed write
+	fallback io.Writer     // receives records the primary failed to write (nil drops them)
+	failed   atomic.Uint64 // failed writes
+	diagAt   atomic.Int64  // unix nanos of the last self-diagnostic
+
+	smp     *sampler  // sampler of Loggers built by New (nil when sampling is off)
+	dup     *dedup    // duplicate suppression (nil when off)
+	hooks   []Hook    // run in order before encoding
+	redact  *redactor // redaction and scrubbing (nil when off)
+	metrics *Metrics  // nil when metrics are off
+}
+
+// ErrorHandler is called with the write error and the record that failed.
+// record is only valid during the call; copy it to retain it.
+type ErrorHandler func(err error, record []byte)
+
+// Clock supplies the current time for records and file rotation.
+// Inject a fake clock to produce deterministic output in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface.
+type ClockFunc func() time.Time
+
+// Now returns f().
+func (f ClockFunc) Now() time.Time { return f() }
+
+// now returns the current time from the configured clock.
+func (c *config) now() time.Time {
+	if c.clock != nil {
+		return c.clock.Now()
+	}
+	return time.Now()
 }
 
 // Option is a functional configuration item for New.
@@ -62,6 +109,36 @@ func WithSkip(skip int) Option {
 	return func(c *config) { c.skip = skip }
 }
 
+// WithClock sets the clock used for record timestamps (default time.Now).
+func WithClock(clk Clock) Option {
+	return func(c *config) { c.clock = clk }
+}
+
+// WithStrictLogfmt sets whether to write strict logfmt: values that are not
+// bare tokens (including Any, Raw and array JSON) are quoted and escaped, and
+// invalid key characters are replaced by '_', so every record parses with any
+// logfmt parser.
+func WithStrictLogfmt(b bool) Option {
+	return func(c *config) { c.enc.Strict = b }
+}
+
+// WithErrorHandler sets a handler called for every failed write.
+func WithErrorHandler(h ErrorHandler) Option {
+	return func(c *config) { c.onError = h }
+}
+
+// WithFallback sets the writer that receives records the output failed to
+// write, together with rate-limited diagnostics (default os.Stderr; nil drops them).
+func WithFallback(w io.Writer) Option {
+	return func(c *config) { c.fallback = w }
+}
+
+// WithSampling enables record sampling for the Logger built by New and
+// the Loggers derived from it, which share one budget (see Sampling).
+func WithSampling(s Sampling) Option {
+	return func(c *config) { c.smp = newSampler(s) }
+}
+
 // FileOption is a file-related configuration item for NewFile.
 type FileOption func(*file.Writer)
 
@@ -74,6 +151,11 @@ func WithMaxSize(ms int64) FileOption { return func(fw *file.Writer) { fw.SetMax
 // WithConsole sets whether to also output to the console.
 func WithConsole(b bool) FileOption { return func(fw *file.Writer) { fw.SetConsole(b) } }
 
+// WithFileClock sets the clock used for file creation times, backup names
+// and maxage cleanup (default time.Now). Use the same clock as WithClock so
+// that daily rotation follows the record timestamps.
+func WithFileClock(clk Clock) FileOption { return func(fw *file.Writer) { fw.SetClock(clk) } }
+
 // ----- Runtime modification entry (for package-level default instance only) -----
 
 // setLevel sets the log level.
@@ -123,6 +205,16 @@ func (c *config) setFile(path string) {
 	c.out = c.fw
 }
 
+// setErrorHandler sets the write error handler.
+func (c *config) setErrorHandler(h ErrorHandler) {
+	c.onError = h
+}
+
+// setFallback sets the fallback writer (nil drops failed records).
+func (c *config) setFallback(w io.Writer) {
+	c.fallback = w
+}
+
 // setMaxAge sets the file writer's max retention days (no-op without a file writer).
 func (c *config) setMaxAge(ma int) {
 	if c.fw == nil {
@@ -147,8 +239,12 @@ func (c *config) setConsole(b bool) {
 	c.fw.SetConsole(b)
 }
 
-// close closes the underlying file writer if any. file.Writer.Close is idempotent (CAS).
+// close emits pending repeat summaries and closes the underlying file writer
+// if any. file.Writer.Close is idempotent (CAS).
 func (c *config) close() error {
+	if c.dup != nil {
+		c.dup.flush(c)
+	}
 	if c.fw != nil {
 		return c.fw.Close()
 	}
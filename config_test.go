@@ -2,9 +2,13 @@ package logs
 
 import (
 	"bytes"
+	"errors"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestNewWithOptions verifies New applies functional options at construction.
@@ -281,3 +285,94 @@ func TestParseLevel(t *testing.T) {
 		}
 	}
 }
+
+// fakeClock is a manually advanced Clock.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// TestWithClock verifies records are stamped by the injected clock, byte for byte.
+func TestWithClock(t *testing.T) {
+	var buf bytes.Buffer
+	clk := &fakeClock{t: time.Date(2026, 5, 9, 10, 11, 12, 345e6, time.UTC)}
+	l := New(&buf, WithHijack(false), WithClock(clk))
+	l.Info("start")
+	clk.Advance(time.Second)
+	l.With("api").Str("k", "v").Warn("slow")
+	l.Errorf("code=%d", 7)
+	want := "time=2026-05-09T10:11:12.345 level=INF msg=start\n" +
+		"time=2026-05-09T10:11:13.345 level=WRN trace=api k=v msg=slow\n" +
+		"time=2026-05-09T10:11:13.345 level=ERR msg=code=7\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("golden mismatch:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+// TestFileClockRollover verifies a shared clock drives midnight rotation without sleeping.
+func TestFileClockRollover(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clk := &fakeClock{t: time.Date(2026, 5, 9, 23, 59, 59, 0, time.UTC)}
+	w, closer := NewFile(path, WithConsole(false), WithFileClock(clk))
+	l := New(w, WithHijack(false), WithClock(clk))
+	l.Info("before")
+	clk.Advance(2 * time.Second)
+	l.Info("after")
+	if err := closer(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	old, err := os.ReadFile(filepath.Join(dir, "app.2026-05-09-235959.log"))
+	if err != nil || !strings.Contains(string(old), "msg=before") || strings.Contains(string(old), "msg=after") {
+		t.Fatalf("backup mismatch: %q %v", old, err)
+	}
+	cur, err := os.ReadFile(path)
+	if err != nil || !strings.HasPrefix(string(cur), "time=2026-05-10T00:00:01.000") {
+		t.Fatalf("current file mismatch: %q %v", cur, err)
+	}
+}
+
+// failWriter fails while fail is set.
+type failWriter struct {
+	fail bool
+	buf  bytes.Buffer
+}
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errors.New("disk full")
+	}
+	return w.buf.Write(p)
+}
+
+// TestWriteErrors verifies failed writes reach the handler, the counter and the fallback.
+func TestWriteErrors(t *testing.T) {
+	out := &failWriter{fail: true}
+	var fb bytes.Buffer
+	var handled []string
+	clk := &fakeClock{t: time.Date(2026, 5, 9, 10, 0, 0, 0, time.UTC)}
+	l := New(out, WithHijack(false), WithClock(clk), WithFallback(&fb),
+		WithErrorHandler(func(err error, rec []byte) { handled = append(handled, err.Error()+"|"+string(rec)) }))
+	l.Info("a")
+	l.Infof("%s", "b")
+	if l.WriteErrors() != 2 || len(handled) != 2 || !strings.HasPrefix(handled[0], "disk full|time=") {
+		t.Fatalf("handler/counter mismatch: %d %q", l.WriteErrors(), handled)
+	}
+	lines := strings.Split(strings.TrimSpace(fb.String()), "\n")
+	if len(lines) != 3 || lines[0] != "logs: write failed: disk full (1 failed writes)" ||
+		!strings.HasSuffix(lines[1], "msg=a") || !strings.HasSuffix(lines[2], "msg=b") {
+		t.Fatalf("fallback mismatch (one diagnostic then records):\n%s", fb.String())
+	}
+	clk.Advance(time.Minute)
+	fb.Reset()
+	l.Info("c")
+	if !strings.HasPrefix(fb.String(), "logs: write failed: disk full (3 failed writes)\n") {
+		t.Fatalf("diagnostic not repeated after the interval: %q", fb.String())
+	}
+	out.fail = false
+	fb.Reset()
+	l.Info("d")
+	if fb.Len() != 0 || !strings.Contains(out.buf.String(), "msg=d") || l.WriteErrors() != 3 {
+		t.Fatalf("recovered output should bypass the fallback: %q", fb.String())
+	}
+}
@@ -0,0 +1,147 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zxysilent/logs/internal/logfmt"
+)
+
+// dedup suppresses repeats of the last record. A record repeats another when
+// everything after its timestamp (level, namespace, caller, fields and msg)
+// is identical. Repeats within the window are counted and reported as one
+// summary record when a different record arrives or the window elapses.
+// The window is measured on the Logger's clock.
+type dedup struct {
+	window time.Duration
+	mu     sync.Mutex
+	last   []byte          // last record written
+	lay    layout          // layout of last
+	ctx    context.Context // context, trace and level of last
+	trace  string
+	lv     Level
+	at     time.Time // when last was written
+	count  int       // repeats suppressed since last
+	first  time.Time // first suppressed repeat
+	recent time.Time // latest suppressed repeat
+	timer  *time.Timer
+	gen    uint64 // timer generation, guards against stale expiries
+}
+
+// WithDedup suppresses consecutive duplicate records within window and emits
+// a "msg=... (repeated N times)" summary with first/last fields once the
+// burst ends or the window elapses. The summary goes through hooks and
+// metrics like any record; suppressed repeats are not counted as written.
+// Logger.Flush writes a pending summary. window <= 0 disables suppression.
+func WithDedup(window time.Duration) Option {
+	return func(c *config) {
+		c.dup = nil
+		if window > 0 {
+			c.dup = &dedup{window: window}
+		}
+	}
+}
+
+// summaryKey marks the context of a repeat summary, which is written
+// without a stack of its own and is not itself deduplicated.
+type summaryKey struct{}
+
+// isSummary reports whether ctx is the context of a repeat summary.
+func isSummary(ctx context.Context) bool {
+	return ctx != nil && ctx.Value(summaryKey{}) != nil
+}
+
+// body returns the record without its leading timestamp.
+func body(p []byte) []byte {
+	if i := bytes.IndexByte(p, ' '); i >= 0 {
+		return p[i:]
+	}
+	return p
+}
+
+// write writes p unless it repeats the last record within the window, and
+// reports whether p was written.
+func (d *dedup) write(c *config, ctx context.Context, trace string, lv Level, lay layout, p []byte) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := c.now()
+	if len(d.last) > 0 && now.Sub(d.at) < d.window && bytes.Equal(body(p), body(d.last)) {
+		if d.count == 0 {
+			d.first = now
+			d.gen++
+			d.schedule(c, d.gen, d.window-now.Sub(d.at))
+		}
+		d.count++
+		d.recent = now
+		return false
+	}
+	d.summarize(c)
+	c.emit(p)
+	d.last = append(d.last[:0], p...)
+	d.lay, d.ctx, d.trace, d.lv = lay, ctx, trace, lv
+	d.at = now
+	return true
+}
+
+// schedule checks the window of timer generation gen again after d. The
+// timer only wakes the check; whether the window elapsed is decided by the clock.
+func (d *dedup) schedule(c *config, gen uint64, after time.Duration) {
+	d.timer = time.AfterFunc(after, func() { d.expire(c, gen) })
+}
+
+// expire ends the window of timer generation gen once it elapsed on the
+// clock: the pending summary is emitted and the last record forgotten, so
+// its next occurrence is written in full.
+func (d *dedup) expire(c *config, gen uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timer == nil || d.gen != gen {
+		return // already summarized
+	}
+	if left := d.window - c.now().Sub(d.at); left > 0 {
+		d.schedule(c, gen, left)
+		return
+	}
+	d.summarize(c)
+	d.last = d.last[:0]
+	d.ctx = nil
+}
+
+// flush emits a pending summary.
+func (d *dedup) flush(c *config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.summarize(c)
+}
+
+// summarize prints the repeat summary of the last record; the caller holds
+// d.mu. The summary keeps the caller and fields of the last record, adds
+// first/last fields and runs through the hooks again, which may veto it.
+func (d *dedup) summarize(c *config) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.count == 0 {
+		return
+	}
+	n := d.count
+	d.count = 0
+	attr := getb()
+	defer putb(attr)
+	*attr = append(*attr, d.last[d.lay.attr:d.lay.hook]...)
+	*attr = append(*attr, d.last[d.lay.fields:d.lay.msg]...)
+	if len(*attr) > 0 && (*attr)[0] == ' ' {
+		*attr = append((*attr)[:0], (*attr)[1:]...)
+	}
+	ctx := d.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithValue(ctx, summaryKey{}, true)
+	msg := logfmt.Decode(d.last[d.lay.msg:]).Msg + " (repeated " + strconv.Itoa(n) + " times)"
+	c.print(ctx, d.trace, d.lv, false, 0, attr, msg, Time("first", d.first), Time("last", d.recent))
+}
@@ -0,0 +1,162 @@
+package logs
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDedupBurst verifies repeats are summarized when a different record arrives.
+func TestDedupBurst(t *testing.T) {
+	var buf bytes.Buffer
+	clk := &fakeClock{t: time.Date(2026, 5, 9, 10, 0, 0, 0, time.UTC)}
+	l := New(&buf, WithHijack(false), WithClock(clk), WithDedup(time.Minute))
+	db := l.With("db").Str("table", "user name").Group()
+	for i := 0; i < 4; i++ {
+		db.Error("query failed")
+		clk.Advance(time.Second)
+	}
+	db.Error("other")
+	want := "time=2026-05-09T10:00:00.000 level=ERR trace=db table=\"user name\" msg=\"query failed\"\n" +
+		"time=2026-05-09T10:00:04.000 level=ERR trace=db table=\"user name\" first=2026-05-09T10:00:01.000 last=2026-05-09T10:00:03.000 msg=\"query failed (repeated 3 times)\"\n" +
+		"time=2026-05-09T10:00:04.000 level=ERR trace=db table=\"user name\" msg=other\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("dedup mismatch:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+// TestDedupFlush verifies Flush writes the pending summary once.
+func TestDedupFlush(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithDedup(time.Hour))
+	l.Info("x")
+	l.Info("x")
+	l.Trace("child").Flush()
+	l.Flush()
+	if got := buf.String(); strings.Count(got, "\n") != 2 || !strings.Contains(got, "msg=\"x (repeated 1 times)\"\n") {
+		t.Fatalf("want the record and one summary, got:\n%s", got)
+	}
+	New(&buf, WithHijack(false)).Flush() // no dedup: no-op
+}
+
+// TestDedupKeys verifies records differing in level, namespace or fields are not merged.
+func TestDedupKeys(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithDedup(time.Minute))
+	l.Info("x")
+	l.Warn("x")
+	l.Trace("a").Warn("x")
+	l.With().Int("n", 1).Warn("x")
+	l.With().Int("n", 2).Warn("x")
+	if n := strings.Count(buf.String(), "\n"); n != 5 {
+		t.Fatalf("want 5 distinct records, got %d:\n%s", n, buf.String())
+	}
+}
+
+// TestDedupWindow verifies the window bounds suppression by the clock and by the timer.
+func TestDedupWindow(t *testing.T) {
+	var buf bytes.Buffer
+	clk := &fakeClock{t: time.Date(2026, 5, 9, 10, 0, 0, 0, time.UTC)}
+	l := New(&buf, WithHijack(false), WithClock(clk), WithDedup(time.Second))
+	l.Info("tick")
+	clk.Advance(2 * time.Second)
+	l.Info("tick") // window elapsed: written in full
+	if n := strings.Count(buf.String(), "msg=tick\n"); n != 2 {
+		t.Fatalf("want 2 full records, got:\n%s", buf.String())
+	}
+
+	var mu sync.Mutex
+	var out bytes.Buffer
+	w := writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return out.Write(p)
+	})
+	l = New(w, WithHijack(false), WithDedup(20*time.Millisecond))
+	l.Info("tick")
+	l.Info("tick")
+	l.Info("tick")
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		got := out.String()
+		mu.Unlock()
+		if strings.Contains(got, `msg="tick (repeated 2 times)"`) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("window timer did not emit a summary:\n%s", out.String())
+}
+
+// TestDedupClock verifies the window timer follows the configured clock, not wall time.
+func TestDedupClock(t *testing.T) {
+	var mu sync.Mutex
+	var out bytes.Buffer
+	w := writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return out.Write(p)
+	})
+	var now atomic.Int64
+	now.Store(time.Date(2026, 5, 9, 10, 0, 0, 0, time.UTC).UnixNano())
+	clk := ClockFunc(func() time.Time { return time.Unix(0, now.Load()).UTC() })
+	l := New(w, WithHijack(false), WithClock(clk), WithDedup(10*time.Millisecond))
+	l.Info("tick")
+	l.Info("tick")
+	time.Sleep(50 * time.Millisecond) // wall time passes, the clock does not
+	mu.Lock()
+	got := out.String()
+	mu.Unlock()
+	if strings.Contains(got, "repeated") {
+		t.Fatalf("summary emitted before the window elapsed on the clock:\n%s", got)
+	}
+	now.Add(int64(time.Second))
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		got = out.String()
+		mu.Unlock()
+		if strings.Contains(got, "time=2026-05-09T10:00:01.000 level=INF first=") {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("summary not emitted after the clock advanced:\n%s", got)
+}
+
+// TestDedupHooksMetrics verifies the summary runs through hooks and metrics,
+// and that suppressed repeats are not counted as written.
+func TestDedupHooksMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	var msgs []string
+	hook := HookFunc(func(e *Entry) bool {
+		msgs = append(msgs, e.Message)
+		e.Str("req", "r1")
+		return true
+	})
+	m := NewMetrics()
+	l := New(&buf, WithHijack(false), WithDedup(time.Minute), WithHooks(hook), WithMetrics(m))
+	for i := 0; i < 4; i++ {
+		l.Error("boom")
+	}
+	l.Error("other")
+	want := []string{"boom", "boom", "boom", "boom", "other", "boom (repeated 3 times)"}
+	if strings.Join(msgs, "|") != strings.Join(want, "|") {
+		t.Fatalf("hooks saw %q, want %q", msgs, want)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || strings.Count(lines[1], "req=r1") != 1 || !strings.Contains(lines[1], "first=") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+	if n := m.Snapshot().Records["ERR"]; n != 3 {
+		t.Fatalf("want 3 written ERR records, got %d", n)
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,170 @@
+package logs
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+)
+
+// Bounds on what ErrDetail writes for a single error.
+const (
+	maxErrDepth    = 16 // nested causes
+	maxStackFrames = 32 // frames per stack
+)
+
+// ErrKey adds an error field under key (nil renders as nil), so that one
+// record can carry several errors.
+func (s *fielder) ErrKey(key string, err error) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	*s.attr = putTextField(s.cfg, *s.attr, key, err, s.cfg.enc.PutError)
+	return s
+}
+
+// ErrDetail adds an error field with its Go type, stack trace and causes:
+//
+//	error="load: open x: no such file" error.type=*fmt.wrapError
+//	error.causes="[{\"type\":\"*fs.PathError\",\"msg\":\"open x: no such file\",\"causes\":[...]}]"
+//
+// Causes come from Unwrap() error and Unwrap() []error (errors.Join). Stacks
+// come from errors with a StackTrace() []uintptr method, a pkg/errors style
+// StackTrace() returning program counters, or a Frames() []runtime.Frame method.
+func (s *fielder) ErrDetail(err error) *fielder {
+	return s.ErrDetailKey(errorFieldName, err)
+}
+
+// ErrDetailKey is ErrDetail with a custom key.
+func (s *fielder) ErrDetailKey(key string, err error) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	*s.attr = putTextField(s.cfg, *s.attr, key, err, s.cfg.enc.PutError)
+	if err != nil && s.cfg.redact.lookup(key).mask == nil {
+		*s.attr = putObject(s.cfg, *s.attr, key, errLayer{err: err, seps: s.cfg.sep})
+	}
+	return s
+}
+
+// errLayer marshals one layer of an error tree.
+type errLayer struct {
+	err   error
+	depth int
+	msg   bool // write the message (the top layer has it as the field value)
+	seps  []string
+}
+
+func (l errLayer) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("type", reflect.TypeOf(l.err).String())
+	if l.msg {
+		enc.AddString("msg", errorText(l.err))
+	}
+	if frames := errFrames(l.err); len(frames) > 0 {
+		if err := enc.AddArray("stack", stackFrames{frames, l.seps}); err != nil {
+			return err
+		}
+	}
+	if causes := errCauses(l.err); len(causes) > 0 && l.depth < maxErrDepth {
+		return enc.AddArray("causes", errLayers{causes, l.depth + 1, l.seps})
+	}
+	return nil
+}
+
+// errLayers marshals the causes of an error.
+type errLayers struct {
+	errs  []error
+	depth int
+	seps  []string
+}
+
+func (errLayers) freeText() {}
+
+func (ls errLayers) MarshalLogArray(enc ArrayEncoder) error {
+	for _, err := range ls.errs {
+		if e := enc.AppendObject(errLayer{err: err, depth: ls.depth, msg: true, seps: ls.seps}); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// errCauses returns the non-nil errors err wraps.
+func errCauses(err error) []error {
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if e := u.Unwrap(); e != nil {
+			return []error{e}
+		}
+	case interface{ Unwrap() []error }:
+		var errs []error
+		for _, e := range u.Unwrap() {
+			if e != nil {
+				errs = append(errs, e)
+			}
+		}
+		return errs
+	}
+	return nil
+}
+
+// errFrames returns the stack trace carried by err, if any.
+func errFrames(err error) []runtime.Frame {
+	switch e := err.(type) {
+	case interface{ Frames() []runtime.Frame }:
+		return e.Frames()
+	case interface{ StackTrace() []uintptr }:
+		return pcFrames(e.StackTrace())
+	}
+	// pkg/errors returns a named []Frame with uintptr elements.
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+	out := m.Call(nil)[0]
+	if out.Kind() != reflect.Slice || out.Type().Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	pcs := make([]uintptr, out.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(out.Index(i).Uint())
+	}
+	return pcFrames(pcs)
+}
+
+// pcFrames resolves program counters (as returned by runtime.Callers).
+func pcFrames(pcs []uintptr) []runtime.Frame {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var frames []runtime.Frame
+	it := runtime.CallersFrames(pcs)
+	for {
+		f, more := it.Next()
+		frames = append(frames, f)
+		if !more || len(frames) == maxStackFrames {
+			return frames
+		}
+	}
+}
+
+// stackFrames marshals frames as "func file:line" strings.
+type stackFrames struct {
+	frames []runtime.Frame
+	seps   []string
+}
+
+func (stackFrames) freeText() {}
+
+func (st stackFrames) MarshalLogArray(enc ArrayEncoder) error {
+	for i, f := range st.frames {
+		if i == maxStackFrames {
+			break
+		}
+		file := f.File
+		if slash := lastSep(file, st.seps); slash >= 0 {
+			file = file[slash:]
+		}
+		enc.AppendString(f.Function + " " + file + ":" + strconv.Itoa(f.Line))
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// pkgFrame and pkgStack mimic pkg/errors' Frame and StackTrace types.
+type pkgFrame uintptr
+type pkgStack []pkgFrame
+
+type stackErr struct {
+	msg string
+	pcs []uintptr
+}
+
+func (e *stackErr) Error() string { return e.msg }
+
+func (e *stackErr) StackTrace() pkgStack {
+	st := make(pkgStack, len(e.pcs))
+	for i, pc := range e.pcs {
+		st[i] = pkgFrame(pc)
+	}
+	return st
+}
+
+func newStackErr(msg string) error {
+	pcs := make([]uintptr, 8)
+	return &stackErr{msg: msg, pcs: pcs[:runtime.Callers(1, pcs)]}
+}
+
+type errLayerJSON struct {
+	Type   string         `json:"type"`
+	Msg    string         `json:"msg"`
+	Stack  []string       `json:"stack"`
+	Causes []errLayerJSON `json:"causes"`
+}
+
+// detail decodes the ErrDetail fields written under key; the record must
+// parse with the strict logfmt grammar.
+func detail(t *testing.T, line, key string) (msg, typ string, stack []string, causes []errLayerJSON) {
+	t.Helper()
+	fields, err := parseStrict(strings.TrimSuffix(line, "\n"))
+	if err != nil {
+		t.Fatalf("bad record %q: %v", line, err)
+	}
+	for _, f := range fields {
+		var err error
+		switch f.Key {
+		case key:
+			msg = f.Val
+		case key + ".type":
+			typ = f.Val
+		case key + ".stack":
+			err = json.Unmarshal([]byte(f.Val), &stack)
+		case key + ".causes":
+			err = json.Unmarshal([]byte(f.Val), &causes)
+		}
+		if err != nil {
+			t.Fatalf("bad %s: %v in %s", f.Key, err, line)
+		}
+	}
+	return
+}
+
+// TestErrDetail verifies types, causes and joins of a wrapped error tree.
+func TestErrDetail(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	_, openErr := os.Open("/nonexistent/x")
+	err := fmt.Errorf("load: %w", errors.Join(openErr, errors.New("second")))
+	l.With().ErrDetail(err).Info("failed")
+	msg, typ, stack, causes := detail(t, buf.String(), "error")
+	if msg != err.Error() || typ != "*fmt.wrapError" || stack != nil {
+		t.Fatalf("top layer mismatch: %q %q %v\n%s", msg, typ, stack, buf.String())
+	}
+	if len(causes) != 1 || causes[0].Type != "*errors.joinError" || len(causes[0].Causes) != 2 {
+		t.Fatalf("join layer mismatch: %+v", causes)
+	}
+	path, second := causes[0].Causes[0], causes[0].Causes[1]
+	if path.Type != "*fs.PathError" || !strings.HasPrefix(path.Msg, "open /nonexistent/x") ||
+		len(path.Causes) != 1 || path.Causes[0].Type != "syscall.Errno" {
+		t.Fatalf("path layer mismatch: %+v", path)
+	}
+	if second.Type != "*errors.errorString" || second.Msg != "second" || second.Causes != nil {
+		t.Fatalf("second layer mismatch: %+v", second)
+	}
+	var pe *fs.PathError
+	if !errors.As(err, &pe) {
+		t.Fatal("test error lost its cause")
+	}
+}
+
+// TestErrDetailStack verifies stacks are read from pkg/errors style errors.
+func TestErrDetailStack(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	l.With().ErrDetail(fmt.Errorf("wrap: %w", newStackErr("deep"))).ErrDetailKey("nil", nil).Info("x")
+	_, _, _, causes := detail(t, buf.String(), "error")
+	if len(causes) != 1 || causes[0].Type != "*logs.stackErr" || len(causes[0].Stack) == 0 {
+		t.Fatalf("stack missing: %+v\n%s", causes, buf.String())
+	}
+	if top := causes[0].Stack[0]; !strings.HasPrefix(top, "github.com/zxysilent/logs.newStackErr ") ||
+		!strings.Contains(top, "errdetail_test.go:") {
+		t.Fatalf("top frame = %q", top)
+	}
+	if !strings.Contains(buf.String(), " nil=nil ") || strings.Contains(buf.String(), "nil.type") {
+		t.Fatalf("nil error mismatch: %s", buf.String())
+	}
+	buf.Reset()
+	l.With().ErrDetail(newStackErr("top")).Info("x")
+	if _, _, stack, _ := detail(t, buf.String(), "error"); len(stack) == 0 ||
+		!strings.HasPrefix(stack[0], "github.com/zxysilent/logs.newStackErr ") {
+		t.Fatalf("top-level stack mismatch: %q\n%s", stack, buf.String())
+	}
+}
+
+// TestErrKey verifies several errors per record under distinct keys.
+func TestErrKey(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	l.With().ErrKey("read_err", errors.New("r")).ErrKey("close_err", nil).Info("io")
+	l.Log(LevelWarn, "io", ErrKey("read_err", errors.New("r")), Err(errors.New("e")))
+	out := buf.String()
+	if !strings.Contains(out, "read_err=r close_err=nil msg=io") || !strings.Contains(out, "read_err=r error=e msg=io") {
+		t.Fatalf("ErrKey mismatch:\n%s", out)
+	}
+}
@@ -1,18 +1,24 @@
 package logs
 
+import "context"
+
 // fielder is a one-time chain builder for accumulating fields.
 type fielder struct {
 	attr   *buffer //调用输出后清空
 	cfg    *config
+	smp    *sampler        // nil when sampling is off
+	ctx    context.Context // set by Ctx, passed to hooks
 	trace  string
 	caller bool
 	skip   bool
+	depth  int   // extra caller frames skipped (set by Skip)
+	lv     Level // level of Msg/Msgf (set by At; INF otherwise)
 }
 
 // Group 将当前 fielder 攒好的字段固化为持久、可复用的 *Logger。调用后原 fielder 被释放，不可再使用。
 // Group solidifies the accumulated fields into a persistent *Logger. The original fielder is released and must not be used again.
 func (s *fielder) Group() *Logger {
-	c := &Logger{cfg: s.cfg, trace: s.trace}
+	c := &Logger{cfg: s.cfg, smp: s.smp, trace: s.trace}
 	if s.attr != nil && len(*s.attr) > 0 {
 		c.attr = make([]byte, len(*s.attr))
 		copy(c.attr, *s.attr)
@@ -27,66 +33,121 @@ func (s *fielder) Caller(b bool) *fielder {
 	return s
 }
 
+// Skip skips n more caller frames for this record only, on top of WithSkip.
+// It also applies to the record's stack field.
+func (s *fielder) Skip(n int) *fielder {
+	s.depth += n
+	return s
+}
+
+// Msg emits the accumulated fields at the chain level (see Logger.At), then releases the fielder.
+func (fl *fielder) Msg(args ...any) {
+	if !fl.skip && fl.lv >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, fl.lv, fl.depth, msgOf(args))) {
+		fl.cfg.print(fl.ctx, fl.trace, fl.lv, fl.caller, fl.depth, fl.attr, args...)
+	}
+	putfl(fl)
+}
+
+// Msgf emits the accumulated fields with a formatted message at the chain level, then releases the fielder.
+func (fl *fielder) Msgf(format string, args ...any) {
+	if !fl.skip && fl.lv >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, fl.lv, fl.depth, format)) {
+		fl.cfg.printf(fl.ctx, fl.trace, fl.lv, fl.caller, fl.depth, fl.attr, format, args...)
+	}
+	putfl(fl)
+}
+
 // Debug emits the accumulated fields at debug level, then releases the fielder.
 func (fl *fielder) Debug(args ...any) {
-	if !fl.skip && LevelDebug >= fl.cfg.level {
-		fl.cfg.print(fl.trace, LevelDebug, fl.caller, fl.attr, args...)
+	if !fl.skip && LevelDebug >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelDebug, fl.depth, msgOf(args))) {
+		fl.cfg.print(fl.ctx, fl.trace, LevelDebug, fl.caller, fl.depth, fl.attr, args...)
 	}
 	putfl(fl)
 }
 
 // Debugf emits the accumulated fields with a formatted message at debug level, then releases the fielder.
 func (fl *fielder) Debugf(format string, args ...any) {
-	if !fl.skip && LevelDebug >= fl.cfg.level {
-		fl.cfg.printf(fl.trace, LevelDebug, fl.caller, fl.attr, format, args...)
+	if !fl.skip && LevelDebug >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelDebug, fl.depth, format)) {
+		fl.cfg.printf(fl.ctx, fl.trace, LevelDebug, fl.caller, fl.depth, fl.attr, format, args...)
 	}
 	putfl(fl)
 }
 
 // Info emits the accumulated fields at info level, then releases the fielder.
 func (fl *fielder) Info(args ...any) {
-	if !fl.skip && LevelInfo >= fl.cfg.level {
-		fl.cfg.print(fl.trace, LevelInfo, fl.caller, fl.attr, args...)
+	if !fl.skip && LevelInfo >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelInfo, fl.depth, msgOf(args))) {
+		fl.cfg.print(fl.ctx, fl.trace, LevelInfo, fl.caller, fl.depth, fl.attr, args...)
 	}
 	putfl(fl)
 }
 
 // Infof emits the accumulated fields with a formatted message at info level, then releases the fielder.
 func (fl *fielder) Infof(format string, args ...any) {
-	if !fl.skip && LevelInfo >= fl.cfg.level {
-		fl.cfg.printf(fl.trace, LevelInfo, fl.caller, fl.attr, format, args...)
+	if !fl.skip && LevelInfo >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelInfo, fl.depth, format)) {
+		fl.cfg.printf(fl.ctx, fl.trace, LevelInfo, fl.caller, fl.depth, fl.attr, format, args...)
 	}
 	putfl(fl)
 }
 
 // Warn emits the accumulated fields at warn level, then releases the fielder.
 func (fl *fielder) Warn(args ...any) {
-	if !fl.skip && LevelWarn >= fl.cfg.level {
-		fl.cfg.print(fl.trace, LevelWarn, fl.caller, fl.attr, args...)
+	if !fl.skip && LevelWarn >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelWarn, fl.depth, msgOf(args))) {
+		fl.cfg.print(fl.ctx, fl.trace, LevelWarn, fl.caller, fl.depth, fl.attr, args...)
 	}
 	putfl(fl)
 }
 
 // Warnf emits the accumulated fields with a formatted message at warn level, then releases the fielder.
 func (fl *fielder) Warnf(format string, args ...any) {
-	if !fl.skip && LevelWarn >= fl.cfg.level {
-		fl.cfg.printf(fl.trace, LevelWarn, fl.caller, fl.attr, format, args...)
+	if !fl.skip && LevelWarn >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelWarn, fl.depth, format)) {
+		fl.cfg.printf(fl.ctx, fl.trace, LevelWarn, fl.caller, fl.depth, fl.attr, format, args...)
 	}
 	putfl(fl)
 }
 
 // Error emits the accumulated fields at error level, then releases the fielder.
 func (fl *fielder) Error(args ...any) {
-	if !fl.skip && LevelError >= fl.cfg.level {
-		fl.cfg.print(fl.trace, LevelError, fl.caller, fl.attr, args...)
+	if !fl.skip && LevelError >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelError, fl.depth, msgOf(args))) {
+		fl.cfg.print(fl.ctx, fl.trace, LevelError, fl.caller, fl.depth, fl.attr, args...)
 	}
 	putfl(fl)
 }
 
 // Errorf emits the accumulated fields with a formatted message at error level, then releases the fielder.
 func (fl *fielder) Errorf(format string, args ...any) {
-	if !fl.skip && LevelError >= fl.cfg.level {
-		fl.cfg.printf(fl.trace, LevelError, fl.caller, fl.attr, format, args...)
+	if !fl.skip && LevelError >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelError, fl.depth, format)) {
+		fl.cfg.printf(fl.ctx, fl.trace, LevelError, fl.caller, fl.depth, fl.attr, format, args...)
+	}
+	putfl(fl)
+}
+
+// Debugw emits the accumulated fields and key/value pairs at debug level, then releases the fielder.
+func (fl *fielder) Debugw(msg string, kv ...any) {
+	if !fl.skip && LevelDebug >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelDebug, fl.depth, msg)) {
+		fl.cfg.printw(fl.ctx, fl.trace, LevelDebug, fl.caller, fl.depth, fl.attr, nil, msg, kv...)
+	}
+	putfl(fl)
+}
+
+// Infow emits the accumulated fields and key/value pairs at info level, then releases the fielder.
+func (fl *fielder) Infow(msg string, kv ...any) {
+	if !fl.skip && LevelInfo >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelInfo, fl.depth, msg)) {
+		fl.cfg.printw(fl.ctx, fl.trace, LevelInfo, fl.caller, fl.depth, fl.attr, nil, msg, kv...)
+	}
+	putfl(fl)
+}
+
+// Warnw emits the accumulated fields and key/value pairs at warn level, then releases the fielder.
+func (fl *fielder) Warnw(msg string, kv ...any) {
+	if !fl.skip && LevelWarn >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelWarn, fl.depth, msg)) {
+		fl.cfg.printw(fl.ctx, fl.trace, LevelWarn, fl.caller, fl.depth, fl.attr, nil, msg, kv...)
+	}
+	putfl(fl)
+}
+
+// Errorw emits the accumulated fields and key/value pairs at error level, then releases the fielder.
+func (fl *fielder) Errorw(msg string, kv ...any) {
+	if !fl.skip && LevelError >= fl.cfg.level && (fl.smp == nil || fl.smp.allow(fl.cfg, LevelError, fl.depth, msg)) {
+		fl.cfg.printw(fl.ctx, fl.trace, LevelError, fl.caller, fl.depth, fl.attr, nil, msg, kv...)
 	}
 	putfl(fl)
 }
@@ -0,0 +1,220 @@
+package logs
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/zxysilent/logs/internal/textenc"
+)
+
+type fieldKind uint8
+
+const (
+	kindAny fieldKind = iota
+	kindString
+	kindInt64
+	kindUint64
+	kindFloat64
+	kindBool
+	kindDuration
+	kindTime
+	kindError
+	kindStringer
+	kindObject
+	kindArray
+)
+
+// Field is a typed key/value pair built ahead of the log call. Scalars are
+// stored unboxed, so building and encoding them does not allocate. Fields
+// are immutable values and may be kept and reused across records.
+type Field struct {
+	Key  string
+	kind fieldKind
+	num  uint64 // int64, uint64, float64 bits, bool, duration, unix seconds
+	nsec int32  // nanoseconds of a time
+	str  string
+	obj  any // error, Stringer, marshaler, Any value or *time.Location
+}
+
+// String builds a string field.
+func String(key, val string) Field { return Field{Key: key, kind: kindString, str: val} }
+
+// Int builds an int field.
+func Int(key string, val int) Field { return Field{Key: key, kind: kindInt64, num: uint64(val)} }
+
+// Int64 builds an int64 field.
+func Int64(key string, val int64) Field { return Field{Key: key, kind: kindInt64, num: uint64(val)} }
+
+// Uint builds a uint field.
+func Uint(key string, val uint) Field { return Field{Key: key, kind: kindUint64, num: uint64(val)} }
+
+// Uint64 builds a uint64 field.
+func Uint64(key string, val uint64) Field { return Field{Key: key, kind: kindUint64, num: val} }
+
+// Float64 builds a float64 field.
+func Float64(key string, val float64) Field {
+	return Field{Key: key, kind: kindFloat64, num: math.Float64bits(val)}
+}
+
+// Bool builds a bool field.
+func Bool(key string, val bool) Field {
+	f := Field{Key: key, kind: kindBool}
+	if val {
+		f.num = 1
+	}
+	return f
+}
+
+// Duration builds a time.Duration field.
+func Duration(key string, val time.Duration) Field {
+	return Field{Key: key, kind: kindDuration, num: uint64(val)}
+}
+
+// Time builds a time.Time field (nanosecond precision, location kept). It
+// stores unix seconds and nanoseconds apart, so every year round-trips.
+func Time(key string, val time.Time) Field {
+	return Field{Key: key, kind: kindTime, num: uint64(val.Unix()), nsec: int32(val.Nanosecond()), obj: val.Location()}
+}
+
+// Err builds an "error" field (nil renders as nil). It is the typed
+// counterpart of fielder.Err; the name Error is taken by the level function.
+func Err(err error) Field { return Field{Key: errorFieldName, kind: kindError, obj: err} }
+
+// ErrKey builds an error field under key, for records with several errors.
+func ErrKey(key string, err error) Field { return Field{Key: key, kind: kindError, obj: err} }
+
+// Stringer builds a field from a fmt.Stringer.
+func Stringer(key string, val fmt.Stringer) Field {
+	return Field{Key: key, kind: kindStringer, obj: val}
+}
+
+// Object builds an ObjectMarshaler field, flattened into dotted keys.
+func Object(key string, val ObjectMarshaler) Field {
+	return Field{Key: key, kind: kindObject, obj: val}
+}
+
+// Array builds an ArrayMarshaler field, written as a JSON array.
+func Array(key string, val ArrayMarshaler) Field { return Field{Key: key, kind: kindArray, obj: val} }
+
+// Any builds a JSON-marshaled field.
+func Any(key string, val any) Field { return Field{Key: key, kind: kindAny, obj: val} }
+
+// put appends the field to dst, encoded and redacted as configured by c.
+func (f Field) put(dst []byte, c *config) []byte {
+	switch f.kind {
+	case kindObject:
+		v, _ := f.obj.(ObjectMarshaler)
+		return putObject(c, dst, f.Key, v)
+	case kindArray:
+		v, _ := f.obj.(ArrayMarshaler)
+		return putArray(c, dst, f.Key, v)
+	case kindAny:
+		return putAnyField(c, dst, f.Key, f.obj)
+	}
+	if f.kind == kindString {
+		return putStrField(c, dst, f.Key, f.str)
+	}
+	dst, k := c.putKey(dst, f.Key)
+	if k.mask == nil {
+		if f.kind == kindError || f.kind == kindStringer {
+			if s, ok := c.redact.scrubValue(f.obj); ok {
+				return c.enc.PutStringQuote(dst, s)
+			}
+		}
+		return f.value(c.enc, dst)
+	}
+	return k.apply(c.enc, f.value(c.enc, dst), len(dst))
+}
+
+// value appends the encoded value of a scalar field to dst.
+func (f Field) value(enc textenc.Encoder, dst []byte) []byte {
+	switch f.kind {
+	case kindString:
+		return enc.PutStringQuote(dst, f.str)
+	case kindInt64:
+		return textenc.PutInt64(dst, int64(f.num))
+	case kindUint64:
+		return textenc.PutUint64(dst, f.num)
+	case kindFloat64:
+		return textenc.PutFloat64(dst, math.Float64frombits(f.num))
+	case kindBool:
+		return textenc.PutBool(dst, f.num == 1)
+	case kindDuration:
+		return enc.PutDuration(dst, time.Duration(f.num))
+	case kindTime:
+		t := time.Unix(int64(f.num), int64(f.nsec))
+		if loc, ok := f.obj.(*time.Location); ok {
+			t = t.In(loc)
+		}
+		return textenc.PutTime(dst, t)
+	case kindError:
+		err, _ := f.obj.(error)
+		return enc.PutError(dst, err)
+	case kindStringer:
+		if s, ok := f.obj.(fmt.Stringer); ok {
+			return enc.PutStringer(dst, s)
+		}
+		return textenc.PutNil(dst)
+	default:
+		return enc.PutAny(dst, f.obj)
+	}
+}
+
+// putFields appends fields to dst, encoded and redacted as configured by c.
+func putFields(dst []byte, fields []Field, c *config) []byte {
+	for i := range fields {
+		dst = fields[i].put(dst, c)
+	}
+	return dst
+}
+
+// splitFields separates Field values passed to print-style methods from the
+// message arguments. The first argument is always part of the message, so a
+// single-argument call is not scanned. It returns args unchanged when there
+// are no Fields. Passing a Field through ...any boxes it, which costs one
+// allocation per field, plus one for the split; Log, fielder.Fields and
+// WithFields do not allocate.
+func splitFields(args []any) (rest, fields []any) {
+	for i := 1; i < len(args); i++ {
+		if _, ok := args[i].(Field); !ok {
+			continue
+		}
+		rest = append(make([]any, 0, len(args)), args[:i]...)
+		for _, a := range args[i:] {
+			if _, ok := a.(Field); ok {
+				fields = append(fields, a)
+			} else {
+				rest = append(rest, a)
+			}
+		}
+		return rest, fields
+	}
+	return args, nil
+}
+
+// Fields adds typed fields.
+func (s *fielder) Fields(fields ...Field) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	*s.attr = putFields(*s.attr, fields, s.cfg)
+	return s
+}
+
+// WithFields derives a Logger whose preset fields are extended with fields.
+// It is not spelled With(fields...) because With already takes an optional
+// trace and returns a one-time fielder; l.With().Fields(fields...).Group()
+// is the equivalent chain.
+func (l *Logger) WithFields(fields ...Field) *Logger {
+	c := l.Clone()
+	c.attr = putFields(c.attr, fields, l.cfg)
+	return c
+}
+
+// Log logs msg at lv with typed fields; it does not allocate for scalar fields.
+func (l *Logger) Log(lv Level, msg string, fields ...Field) {
+	if lv >= l.cfg.level && lv < LevelMute && (l.smp == nil || l.smp.allow(l.cfg, lv, 0, msg)) {
+		l.cfg.printw(nil, l.trace, lv, l.cfg.caller, 0, l.preb(), fields, msg)
+	}
+}
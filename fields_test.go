@@ -0,0 +1,100 @@
+package logs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFieldEncoding verifies each Field constructor renders like its fielder counterpart.
+func TestFieldEncoding(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	far := time.Date(2300, 6, 7, 8, 9, 10, 123e6, time.UTC)
+	l.Log(LevelInfo, "typed",
+		String("s", "a b"), Int("i", -1), Int64("i64", 2), Uint("u", 3), Uint64("u64", 4),
+		Float64("f", 0.25), Bool("b", true), Duration("d", time.Second), Time("t", at),
+		Time("zero", time.Time{}), Time("far", far),
+		Err(errors.New("bad")), Err(nil), Stringer("ip", net.IPv4(10, 0, 0, 1)), Any("m", map[string]int{"x": 1}))
+	var want bytes.Buffer
+	New(&want, WithHijack(false)).With().Str("s", "a b").Int("i", -1).Int64("i64", 2).Uint("u", 3).Uint64("u64", 4).
+		Float64("f", 0.25).Bool("b", true).Dur("d", time.Second).Time("t", at).
+		Time("zero", time.Time{}).Time("far", far).
+		Err(errors.New("bad")).Err(nil).Stringer("ip", net.IPv4(10, 0, 0, 1)).Any("m", map[string]int{"x": 1}).Info("typed")
+	got, exp := buf.String(), want.String()
+	if got[strings.Index(got, " level="):] != exp[strings.Index(exp, " level="):] {
+		t.Fatalf("Field encoding mismatch:\n got: %s\nwant: %s", got, exp)
+	}
+	for _, want := range []string{"zero=0001-01-01T00:00:00.000", "far=2300-06-07T08:09:10.123"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in %s", want, got)
+		}
+	}
+}
+
+// TestFieldEntryPoints verifies Fields, WithFields, Info and *w accept Field values.
+func TestFieldEntryPoints(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	base := l.WithFields(String("svc", "api"))
+	base.Info("start", Int("port", 80))
+	base.With("db").Fields(Bool("ok", true)).Warn("ping")
+	l.Info("a", "b", Int("n", 1))
+	l.Infow("kv", "k", 1, Err(errors.New("e")), "z", 2)
+	l.Log(LevelDebug, "hidden")
+	out := buf.String()
+	for _, want := range []string{
+		"level=INF svc=api port=80 msg=start\n",
+		"level=WRN trace=db svc=api ok=true msg=ping\n",
+		"level=INF n=1 msg=ab\n",
+		"level=INF k=1 error=e z=2 msg=kv\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hidden") {
+		t.Fatalf("disabled level written:\n%s", out)
+	}
+	if len(l.attr) != 0 {
+		t.Fatal("WithFields modified the parent logger")
+	}
+}
+
+// TestLogAllocs verifies Log with scalar fields does not allocate.
+func TestLogAllocs(t *testing.T) {
+	l := New(io.Discard, WithHijack(false))
+	if n := testing.AllocsPerRun(100, func() {
+		l.Log(LevelInfo, "x", String("k", "v"), Int("n", 1), Bool("b", true), Duration("d", time.Millisecond))
+	}); n != 0 {
+		t.Fatalf("Log allocates %.0f times", n)
+	}
+}
+
+// TestFieldsAllocs verifies the fielder and WithFields paths do not allocate,
+// while a Field passed to Info is boxed and split from the message.
+func TestFieldsAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items under the race detector")
+	}
+	l := New(io.Discard, WithHijack(false))
+	api := l.WithFields(String("svc", "api"), Int("v", 2))
+	for name, c := range map[string]struct {
+		fn   func()
+		want float64
+	}{
+		"Fields":     {func() { l.With().Fields(String("k", "v"), Int("n", 1)).Info("x") }, 0},
+		"WithFields": {func() { api.Info("x") }, 0},
+		"Info":       {func() { l.Info("x") }, 0},
+		"InfoField":  {func() { l.Info("x", Int("n", 1)) }, 2},
+	} {
+		if n := testing.AllocsPerRun(100, c.fn); n != c.want {
+			t.Fatalf("%s allocates %.0f times, want %.0f", name, n, c.want)
+		}
+	}
+}
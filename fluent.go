@@ -0,0 +1,48 @@
+package logs
+
+import (
+	"io"
+	"time"
+
+	"github.com/zxysilent/logs/internal/fluent"
+)
+
+// FluentOption is a configuration item for NewFluent.
+type FluentOption func(*fluent.Writer)
+
+// WithFluentTag sets the base tag (default "logs").
+func WithFluentTag(tag string) FluentOption { return func(w *fluent.Writer) { w.SetTag(tag) } }
+
+// WithFluentTagDepth sets how many leading trace segments are appended to the
+// tag (default 1: trace=api.req-1 → tag logs.api). Zero uses the base tag
+// only; use it when traces start with a per-request id (Ctx without a
+// namespace), which would otherwise create a tag per request.
+func WithFluentTagDepth(n int) FluentOption { return func(w *fluent.Writer) { w.SetTagDepth(n) } }
+
+// WithFluentAck sets whether each message requests an ack and waits for it.
+func WithFluentAck(b bool) FluentOption { return func(w *fluent.Writer) { w.SetAck(b) } }
+
+// WithFluentBatch sets the number of buffered records that triggers an immediate send (default 256).
+func WithFluentBatch(n int) FluentOption { return func(w *fluent.Writer) { w.SetBatch(n) } }
+
+// WithFluentBuffer sets the maximum number of records buffered while the server is unreachable (default 65536).
+func WithFluentBuffer(n int) FluentOption { return func(w *fluent.Writer) { w.SetBuffer(n) } }
+
+// WithFluentInterval sets the maximum time records wait before being sent (default 1s).
+func WithFluentInterval(d time.Duration) FluentOption {
+	return func(w *fluent.Writer) { w.SetInterval(d) }
+}
+
+// NewFluent opens a Fluentd Forward protocol writer to addr ("host:port" or
+// "unix:///path"), returning the Writer and its close handle.
+// Records are sent as msgpack maps in PackedForward batches per tag;
+// the connection is redialed on failure and records are buffered meanwhile.
+// Without WithFluentAck, a batch written just after the server closed the
+// connection may be lost, since only a failed write reveals the close.
+func NewFluent(addr string, opts ...FluentOption) (io.Writer, func() error) {
+	w := fluent.New(addr)
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w, w.Close
+}
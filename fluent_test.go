@@ -0,0 +1,40 @@
+package logs
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+// TestNewFluent verifies a Logger writing through NewFluent sends a Forward message.
+func TestNewFluent(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	got := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		got <- b
+	}()
+
+	w, closeFn := NewFluent(ln.Addr().String(), WithFluentTag("app"))
+	l := New(w, WithHijack(false))
+	l.Trace("api").With().Str("user", "alice").Info("hello")
+	if err := closeFn(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	b := <-got
+	for _, want := range []string{"app.api", "user", "alice", "hello"} {
+		if !bytes.Contains(b, []byte(want)) {
+			t.Fatalf("forward message missing %q: %q", want, b)
+		}
+	}
+}
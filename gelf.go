@@ -0,0 +1,35 @@
+package logs
+
+import (
+	"io"
+
+	"github.com/zxysilent/logs/internal/gelf"
+)
+
+// GELFOption is a configuration item for NewGELF.
+type GELFOption func(*gelf.Writer)
+
+// WithGELFHost sets the GELF host field (default os.Hostname).
+func WithGELFHost(host string) GELFOption { return func(w *gelf.Writer) { w.SetHost(host) } }
+
+// WithGELFCompress sets the UDP payload compression: "gzip" (default), "zlib" or "none".
+func WithGELFCompress(kind string) GELFOption { return func(w *gelf.Writer) { w.SetCompress(kind) } }
+
+// WithGELFChunkSize sets the maximum UDP datagram size (default 1420, minimum 512).
+// Larger payloads are split into at most 128 GELF chunks.
+func WithGELFChunkSize(n int) GELFOption { return func(w *gelf.Writer) { w.SetChunkSize(n) } }
+
+// NewGELF opens a GELF 1.1 writer for Graylog, returning the Writer and its close handle.
+// addr is "udp://host:port" (default when no scheme) or "tcp://host:port".
+// msg maps to short_message, level to the syslog level, caller to file/line
+// and custom fields to "_"-prefixed additional fields. Records are queued and
+// sent from a background goroutine, so a slow or unreachable server never
+// blocks logging; records beyond the queue are dropped and counted. The close
+// handle sends the queued records before closing the connection.
+func NewGELF(addr string, opts ...GELFOption) (io.Writer, func() error) {
+	w := gelf.New(addr)
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w, w.Close
+}
@@ -0,0 +1,45 @@
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestNewGELF verifies a Logger writing through NewGELF sends a gzip GELF datagram.
+func TestNewGELF(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+
+	w, closeFn := NewGELF(pc.LocalAddr().String(), WithGELFHost("web-1"))
+	defer closeFn()
+	l := New(w, WithHijack(false), WithCaller(true))
+	l.With().Str("user", "alice").Warn("slow request")
+
+	buf := make([]byte, 65536)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(buf[:n]))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	var m map[string]any
+	if err := json.NewDecoder(zr).Decode(&m); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if m["short_message"] != "slow request" || m["level"] != 4.0 || m["host"] != "web-1" || m["_user"] != "alice" {
+		t.Fatalf("gelf message mismatch: %v", m)
+	}
+	if m["file"] != "/gelf_test.go" {
+		t.Fatalf("caller file mismatch: %v", m["file"])
+	}
+}
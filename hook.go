@@ -0,0 +1,117 @@
+package logs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zxysilent/logs/internal/textenc"
+)
+
+// Hook inspects a record before it is encoded and written. Run may add fields
+// through the Entry; returning false vetoes the record.
+type Hook interface {
+	Run(e *Entry) bool
+}
+
+// HookFunc adapts a function to the Hook interface.
+type HookFunc func(e *Entry) bool
+
+// Run returns f(e).
+func (f HookFunc) Run(e *Entry) bool { return f(e) }
+
+// Entry is the record handed to hooks. It is only valid during Run.
+type Entry struct {
+	Level   Level
+	Trace   string          // namespace
+	Message string          // formatted message
+	Ctx     context.Context // nil unless logged via Ctx
+	attr    buffer          // fields added by hooks
+	cfg     *config         // config of the logger
+}
+
+var epool = sync.Pool{New: func() any { return &Entry{attr: make(buffer, 0, 64)} }}
+
+// Str adds a string field to the record.
+func (e *Entry) Str(key, val string) *Entry {
+	e.attr = putStrField(e.cfg, e.attr, key, val)
+	return e
+}
+
+// Int adds an int field to the record.
+func (e *Entry) Int(key string, i int) *Entry {
+	e.attr = putField(e.cfg, e.attr, key, i, textenc.PutInt)
+	return e
+}
+
+// Int64 adds an int64 field to the record.
+func (e *Entry) Int64(key string, i int64) *Entry {
+	e.attr = putField(e.cfg, e.attr, key, i, textenc.PutInt64)
+	return e
+}
+
+// Float64 adds a float64 field to the record.
+func (e *Entry) Float64(key string, f float64) *Entry {
+	e.attr = putField(e.cfg, e.attr, key, f, textenc.PutFloat64)
+	return e
+}
+
+// Bool adds a bool field to the record.
+func (e *Entry) Bool(key string, b bool) *Entry {
+	e.attr = putField(e.cfg, e.attr, key, b, textenc.PutBool)
+	return e
+}
+
+// Dur adds a time.Duration field to the record.
+func (e *Entry) Dur(key string, d time.Duration) *Entry {
+	e.attr = putField(e.cfg, e.attr, key, d, e.cfg.enc.PutDuration)
+	return e
+}
+
+// Any adds an arbitrary value as a JSON-marshaled field to the record.
+func (e *Entry) Any(key string, i any) *Entry {
+	e.attr = putAnyField(e.cfg, e.attr, key, i)
+	return e
+}
+
+// WithHooks appends hooks; they run in registration order for every record
+// that passes the level check, and the first veto stops the chain.
+func WithHooks(hooks ...Hook) Option {
+	return func(c *config) { c.hooks = append(c.hooks, hooks...) }
+}
+
+// runHooks runs the hooks for a record. It returns the Entry holding the
+// fields added by hooks (release it with putEntry), or nil when vetoed.
+func (c *config) runHooks(ctx context.Context, trace string, lv Level, msg string) *Entry {
+	e := epool.Get().(*Entry)
+	e.Level, e.Trace, e.Message, e.Ctx = lv, trace, msg, ctx
+	e.cfg = c
+	for _, h := range c.hooks {
+		if !h.Run(e) {
+			putEntry(e)
+			if c.metrics != nil {
+				c.metrics.drop(dropHook)
+			}
+			return nil
+		}
+	}
+	return e
+}
+
+// putEntry resets e and returns it to the pool, dropping oversized buffers.
+func putEntry(e *Entry) {
+	if cap(e.attr) > maxBufferSize {
+		return
+	}
+	e.attr = e.attr[:0]
+	e.Trace, e.Message, e.Ctx, e.cfg = "", "", nil, nil
+	epool.Put(e)
+}
+
+// putHookAttr appends the fields added by hooks to buf.
+func putHookAttr(buf *buffer, e *Entry) {
+	if e != nil && len(e.attr) > 0 {
+		*buf = textenc.PutDelim(*buf)
+		*buf = append(*buf, e.attr...)
+	}
+}
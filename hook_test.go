@@ -0,0 +1,75 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestHooks verifies hook order, added fields, veto and the entry contents.
+func TestHooks(t *testing.T) {
+	var buf bytes.Buffer
+	var order []string
+	var seen []Entry
+	first := HookFunc(func(e *Entry) bool {
+		order = append(order, "first")
+		seen = append(seen, Entry{Level: e.Level, Trace: e.Trace, Message: e.Message, Ctx: e.Ctx})
+		e.Str("rid", "r-1").Int("n", 2)
+		return e.Message != "drop"
+	})
+	second := HookFunc(func(e *Entry) bool {
+		order = append(order, "second")
+		return true
+	})
+	l := New(&buf, WithHijack(false), WithHooks(first), WithHooks(second))
+	l.Trace("api").Info("hello")
+	l.Warnf("code=%d", 7)
+	l.Info("drop")
+	l.With().Str("k", "v").Error("fields")
+	out := buf.String()
+	want := "level=INF trace=api rid=r-1 n=2 msg=hello\n"
+	if !strings.Contains(out, want) || !strings.Contains(out, "level=WRN rid=r-1 n=2 msg=code=7\n") ||
+		!strings.Contains(out, "level=ERR k=v rid=r-1 n=2 msg=fields\n") {
+		t.Fatalf("hook fields missing:\n%s", out)
+	}
+	if strings.Contains(out, "msg=drop") {
+		t.Fatalf("vetoed record written:\n%s", out)
+	}
+	if got := strings.Join(order, ","); got != "first,second,first,second,first,first,second" {
+		t.Fatalf("hook order = %s", got)
+	}
+	if seen[0].Level != LevelInfo || seen[0].Trace != "api" || seen[0].Ctx != nil || seen[1].Message != "code=7" {
+		t.Fatalf("entry mismatch: %+v", seen)
+	}
+}
+
+type ctxKeyTest struct{}
+
+// TestHookCtx verifies the context of Ctx records reaches hooks.
+func TestHookCtx(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithHooks(HookFunc(func(e *Entry) bool {
+		if e.Ctx != nil {
+			if v, ok := e.Ctx.Value(ctxKeyTest{}).(string); ok {
+				e.Str("user", v)
+			}
+		}
+		return true
+	})))
+	ctx := context.WithValue(context.Background(), ctxKeyTest{}, "alice")
+	l.Ctx(ctx).Infof("%s", "in")
+	l.Info("out")
+	if out := buf.String(); !strings.Contains(out, "user=alice msg=in") || strings.Contains(out, "user=alice msg=out") {
+		t.Fatalf("ctx hook mismatch:\n%s", out)
+	}
+}
+
+// TestNoHooksAllocs verifies the no-hook path stays allocation free.
+func TestNoHooksAllocs(t *testing.T) {
+	l := New(io.Discard, WithHijack(false))
+	if n := testing.AllocsPerRun(100, func() { l.Info("x") }); n != 0 {
+		t.Fatalf("Info allocates %.0f times without hooks", n)
+	}
+}
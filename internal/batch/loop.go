@@ -0,0 +1,97 @@
+// Package batch runs the background flush loop shared by the network
+// writers: a ticker, an early kick when a batch fills up, serialized flushes
+// and an idempotent Close. Writers keep their own queues and supply only the
+// function that encodes and sends them.
+package batch
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Loop calls flush every interval, after Kick, on Flush and once more on Close.
+// Calls to flush never overlap.
+type Loop struct {
+	flush  func() error
+	kick   chan struct{}
+	tk     *time.Ticker
+	mu     sync.Mutex // serializes flush
+	done   chan struct{}
+	exited chan struct{}
+	closed int32
+}
+
+// New starts a Loop calling flush every interval.
+func New(interval time.Duration, flush func() error) *Loop {
+	l := &Loop{
+		flush:  flush,
+		kick:   make(chan struct{}, 1),
+		tk:     time.NewTicker(interval),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go l.daemon()
+	return l
+}
+
+func (l *Loop) daemon() {
+	defer close(l.exited)
+	for {
+		select {
+		case <-l.tk.C:
+			l.Flush()
+		case <-l.kick:
+			l.Flush()
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// SetInterval sets the time between periodic flushes; d <= 0 is ignored.
+func (l *Loop) SetInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	l.tk.Reset(d)
+}
+
+// Kick requests a flush without waiting for it.
+func (l *Loop) Kick() {
+	select {
+	case l.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Flush calls flush, waiting for a flush in progress to finish first.
+func (l *Loop) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.flush()
+}
+
+// Closed reports whether Close has been called.
+func (l *Loop) Closed() bool {
+	return atomic.LoadInt32(&l.closed) != 0
+}
+
+// Close stops the loop and flushes once more. release, if not nil, runs
+// after the final flush, before any later Flush, to free what flush uses
+// (e.g. a connection). Only the first call has any effect.
+func (l *Loop) Close(release func()) error {
+	if !atomic.CompareAndSwapInt32(&l.closed, 0, 1) {
+		return nil
+	}
+	l.tk.Stop()
+	close(l.done)
+	<-l.exited
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.flush()
+	if release != nil {
+		release()
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package batch
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestLoop verifies ticks, kicks and Close each flush, and Close runs once.
+func TestLoop(t *testing.T) {
+	var n, released int32
+	l := New(time.Hour, func() error { atomic.AddInt32(&n, 1); return nil })
+	l.Kick()
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&n) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&n) != 1 {
+		t.Fatalf("kick flushed %d times", n)
+	}
+	l.SetInterval(time.Millisecond)
+	for atomic.LoadInt32(&n) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&n) < 3 {
+		t.Fatalf("ticker flushed %d times", n)
+	}
+	l.Close(func() { atomic.AddInt32(&released, 1) })
+	before := atomic.LoadInt32(&n)
+	l.Close(func() { atomic.AddInt32(&released, 1) })
+	time.Sleep(5 * time.Millisecond)
+	if !l.Closed() || released != 1 || atomic.LoadInt32(&n) != before {
+		t.Fatalf("Close: closed=%v released=%d flushes=%d→%d", l.Closed(), released, before, n)
+	}
+}
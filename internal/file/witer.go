@@ -20,6 +20,15 @@ const (
 
 var _ io.WriteCloser = (*Writer)(nil)
 
+// Clock supplies the current time for creation dates, backup names and cleanup.
+type Clock interface {
+	Now() time.Time
+}
+
+type wallClock struct{}
+
+func (wallClock) Now() time.Time { return time.Now() }
+
 type Writer struct {
 	maxage  int       // max retention days
 	maxsize int64     // max size per file, default 64 MiB
@@ -31,12 +40,17 @@ type Writer struct {
 	fsuffix string    // suffix, default .log
 	created time.Time // file creation date
 	creates []byte    // file creation date for compare
+	clock   Clock     // time source, default wall clock
 	file    *os.File
 	bw      *bufio.Writer
 	tk      *time.Ticker
 	mu      sync.Mutex
+	wg      sync.WaitGroup // in-flight daily cleanup
 	done    chan struct{}
 	closed  int32 // 0 = open, 1 = closed
+
+	rotations atomic.Uint64 // files moved to a backup name
+	deletions atomic.Uint64 // backups removed by maxage cleanup
 }
 
 func New(path string, cons bool) *Writer {
@@ -44,6 +58,7 @@ func New(path string, cons bool) *Writer {
 		fpath:   path, //dir1/dir2/app.log
 		mu:      sync.Mutex{},
 		console: cons,
+		clock:   wallClock{},
 		done:    make(chan struct{}),
 	}
 	w.fdir = filepath.Dir(w.fpath)                                  //dir1/dir2
@@ -95,6 +110,16 @@ func (w *Writer) SetConsole(b bool) {
 	w.console = b
 }
 
+// SetClock sets the time source; nil restores the wall clock.
+func (w *Writer) SetClock(c Clock) {
+	if c == nil {
+		c = wallClock{}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.clock = c
+}
+
 func (w *Writer) equaldate(file []byte, msg []byte) bool {
 	// Only supports zxysilent/logs
 	if len(file) < 10 || len(msg) < 15 {
@@ -114,13 +139,16 @@ func (w *Writer) Write(p []byte) (n int, err error) {
 	}
 	if w.file == nil {
 		if err := w.rotate(); err != nil {
-			os.Stderr.Write(p)
 			return 0, err
 		}
 	}
 	// rotate by day
 	if !w.equaldate(w.creates, p) { //2023-04-05
-		go w.delete(w.maxage) // daily cleanup
+		w.wg.Add(1)
+		go func(maxage int, now time.Time) { // daily cleanup
+			defer w.wg.Done()
+			w.delete(maxage, now)
+		}(w.maxage, w.clock.Now())
 		if err := w.rotate(); err != nil {
 			return 0, err
 		}
@@ -135,6 +163,8 @@ func (w *Writer) Write(p []byte) (n int, err error) {
 	n, err = w.bw.Write(p)
 	w.size += int64(n)
 	if err != nil {
+		// bufio errors are sticky; drop the buffer so later writes retry the file
+		w.bw.Reset(w.file)
 		return n, err
 	}
 	return
@@ -142,14 +172,16 @@ func (w *Writer) Write(p []byte) (n int, err error) {
 
 // rotate closes the current file and opens a new one.
 func (w *Writer) rotate() error {
-	now := time.Now()
+	now := w.clock.Now()
 	if w.file != nil {
 		w.bw.Flush()
 		w.file.Sync()
 		w.file.Close()
 		// save backup
 		fbak := w.fname + w.time2name(w.created) + w.fsuffix
-		os.Rename(w.fpath, filepath.Join(w.fdir, fbak))
+		if os.Rename(w.fpath, filepath.Join(w.fdir, fbak)) == nil {
+			w.rotations.Add(1)
+		}
 		w.size = 0
 	}
 	finfo, err := os.Stat(w.fpath)
@@ -162,6 +194,8 @@ func (w *Writer) rotate() error {
 	os.MkdirAll(w.fdir, 0755)
 	fout, err := os.OpenFile(w.fpath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
 	if err != nil {
+		// retry on the next write instead of writing to the closed file
+		w.file, w.bw = nil, nil
 		return err
 	}
 	w.file = fout
@@ -169,13 +203,13 @@ func (w *Writer) rotate() error {
 	return nil
 }
 
-// delete removes log files older than maxage days.
-func (w *Writer) delete(maxage int) {
+// delete removes log files older than maxage days before now.
+func (w *Writer) delete(maxage int, now time.Time) {
 	if maxage <= 0 {
 		return
 	}
 	dir := w.fdir
-	fakeNow := time.Now().AddDate(0, 0, -maxage)
+	fakeNow := now.AddDate(0, 0, -maxage)
 	dirs, err := os.ReadDir(dir)
 	if err != nil {
 		return
@@ -187,12 +221,18 @@ func (w *Writer) delete(maxage int) {
 		}
 		t, err := w.name2time(name)
 		// only delete files matching the date pattern
-		if err == nil && t.Before(fakeNow) {
-			os.Remove(filepath.Join(dir, name))
+		if err == nil && t.Before(fakeNow) && os.Remove(filepath.Join(dir, name)) == nil {
+			w.deletions.Add(1)
 		}
 	}
 }
 
+// Rotations returns the number of files moved to a backup name.
+func (w *Writer) Rotations() uint64 { return w.rotations.Load() }
+
+// Deletions returns the number of backups removed by maxage cleanup.
+func (w *Writer) Deletions() uint64 { return w.deletions.Load() }
+
 func (w *Writer) name2time(name string) (time.Time, error) {
 	name = strings.TrimPrefix(name, filepath.Base(w.fname))
 	name = strings.TrimSuffix(name, w.fsuffix)
@@ -218,6 +258,7 @@ func (w *Writer) Close() error {
 	}
 	w.tk.Stop()
 	close(w.done)
+	w.wg.Wait()
 	w.flush()
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -11,7 +11,7 @@ import (
 func TestName2time(t *testing.T) {
 	f := New("../../logs/app.log", false)
 	t.Logf("%+v", f)
-	f.delete(f.maxage)
+	f.delete(f.maxage, time.Now())
 }
 
 func TestReadDir(t *testing.T) {
@@ -87,7 +87,7 @@ func TestWriterLifecycle(t *testing.T) {
 		t.Fatalf("create stale file failed: %v", err)
 	}
 	w.maxage = 1
-	w.delete(w.maxage)
+	w.delete(w.maxage, time.Now())
 	if _, err := os.Stat(stale); !os.IsNotExist(err) {
 		t.Fatalf("stale file should be deleted, err=%v", err)
 	}
@@ -234,3 +234,60 @@ func TestWriteCons(t *testing.T) {
 		t.Fatalf("write with cons failed: %v", err)
 	}
 }
+
+type stepClock struct{ t time.Time }
+
+func (c *stepClock) Now() time.Time { return c.t }
+
+// TestClockRetention verifies daily rotation and maxage cleanup over several simulated days.
+func TestClockRetention(t *testing.T) {
+	dir := t.TempDir()
+	w := New(filepath.Join(dir, "app.log"), false)
+	clk := &stepClock{t: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+	w.SetClock(clk)
+	w.SetMaxAge(2)
+	for day := 0; day < 6; day++ {
+		line := clk.t.AppendFormat([]byte("time="), "2006-01-02T15:04:05.000")
+		if _, err := w.Write(append(line, " msg=x\n"...)); err != nil {
+			t.Fatalf("day %d: %v", day, err)
+		}
+		clk.t = clk.t.AddDate(0, 0, 1)
+	}
+	if err := w.Close(); err != nil { // waits for pending cleanup
+		t.Fatalf("close: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"app.2026-05-04-120000.log", "app.2026-05-05-120000.log", "app.log"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+	if w.Rotations() != 5 || w.Deletions() != 3 {
+		t.Fatalf("rotations=%d deletions=%d, want 5 and 3", w.Rotations(), w.Deletions())
+	}
+}
+
+// TestWriteRecovers verifies a failing open is reported and retried on later writes.
+func TestWriteRecovers(t *testing.T) {
+	dir := t.TempDir()
+	block := filepath.Join(dir, "blk")
+	if err := os.WriteFile(block, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w := New(filepath.Join(block, "app.log"), false)
+	defer w.Close()
+	if _, err := w.Write([]byte("a\n")); err == nil {
+		t.Fatal("expected an error while the directory is a file")
+	}
+	os.Remove(block)
+	if _, err := w.Write([]byte("b\n")); err != nil {
+		t.Fatalf("write should recover: %v", err)
+	}
+	w.flush()
+	if b, _ := os.ReadFile(filepath.Join(block, "app.log")); string(b) != "b\n" {
+		t.Fatalf("content = %q", b)
+	}
+}
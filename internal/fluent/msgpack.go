@@ -0,0 +1,283 @@
+package fluent
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// Minimal MessagePack encoder/decoder covering the types used by the Forward protocol.
+
+func appendNil(b []byte) []byte { return append(b, 0xc0) }
+
+func appendBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, 0xc3)
+	}
+	return append(b, 0xc2)
+}
+
+func appendInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0 && v <= 0x7f:
+		return append(b, byte(v))
+	case v < 0 && v >= -32:
+		return append(b, byte(v))
+	}
+	b = append(b, 0xd3)
+	return binary.BigEndian.AppendUint64(b, uint64(v))
+}
+
+func appendFloat(b []byte, v float64) []byte {
+	b = append(b, 0xcb)
+	return binary.BigEndian.AppendUint64(b, math.Float64bits(v))
+}
+
+func appendString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xda)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xdb)
+		b = binary.BigEndian.AppendUint32(b, uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendBin(b []byte, p []byte) []byte {
+	n := len(p)
+	switch {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xc5)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xc6)
+		b = binary.BigEndian.AppendUint32(b, uint32(n))
+	}
+	return append(b, p...)
+}
+
+func appendArray(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xdc)
+		return binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xdd)
+		return binary.BigEndian.AppendUint32(b, uint32(n))
+	}
+}
+
+func appendMap(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xde)
+		return binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xdf)
+		return binary.BigEndian.AppendUint32(b, uint32(n))
+	}
+}
+
+// appendEventTime appends a Forward protocol EventTime (fixext8, type 0).
+func appendEventTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = binary.BigEndian.AppendUint32(b, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+}
+
+// appendValue appends a decoded logfmt value.
+func appendValue(b []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return appendNil(b)
+	case bool:
+		return appendBool(b, v)
+	case int64:
+		return appendInt(b, v)
+	case float64:
+		return appendFloat(b, v)
+	case string:
+		return appendString(b, v)
+	}
+	return appendNil(b)
+}
+
+var errShort = errors.New("fluent: short msgpack data")
+
+// decode decodes one value from b and returns it with the remaining bytes.
+// Maps decode to map[string]any, arrays to []any, bin to []byte and
+// EventTime to time.Time.
+func decode(b []byte) (any, []byte, error) {
+	if len(b) == 0 {
+		return nil, b, errShort
+	}
+	c := b[0]
+	b = b[1:]
+	switch {
+	case c <= 0x7f:
+		return int64(c), b, nil
+	case c >= 0xe0:
+		return int64(int8(c)), b, nil
+	case c&0xe0 == 0xa0:
+		return decodeStr(b, int(c&0x1f))
+	case c&0xf0 == 0x90:
+		return decodeArray(b, int(c&0x0f))
+	case c&0xf0 == 0x80:
+		return decodeMap(b, int(c&0x0f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2:
+		return false, b, nil
+	case 0xc3:
+		return true, b, nil
+	case 0xcc, 0xcd, 0xce, 0xcf, 0xd0, 0xd1, 0xd2, 0xd3:
+		return decodeInt(c, b)
+	case 0xca:
+		if len(b) < 4 {
+			return nil, b, errShort
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), b[4:], nil
+	case 0xcb:
+		if len(b) < 8 {
+			return nil, b, errShort
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), b[8:], nil
+	case 0xd9, 0xda, 0xdb:
+		n, rest, err := decodeLen(c-0xd9, b)
+		if err != nil {
+			return nil, b, err
+		}
+		return decodeStr(rest, n)
+	case 0xc4, 0xc5, 0xc6:
+		n, rest, err := decodeLen(c-0xc4, b)
+		if err != nil {
+			return nil, b, err
+		}
+		if len(rest) < n {
+			return nil, b, errShort
+		}
+		return append([]byte(nil), rest[:n]...), rest[n:], nil
+	case 0xdc, 0xdd:
+		n, rest, err := decodeLen(c-0xdc+1, b)
+		if err != nil {
+			return nil, b, err
+		}
+		return decodeArray(rest, n)
+	case 0xde, 0xdf:
+		n, rest, err := decodeLen(c-0xde+1, b)
+		if err != nil {
+			return nil, b, err
+		}
+		return decodeMap(rest, n)
+	case 0xd7:
+		if len(b) < 9 {
+			return nil, b, errShort
+		}
+		sec := binary.BigEndian.Uint32(b[1:])
+		nsec := binary.BigEndian.Uint32(b[5:])
+		return time.Unix(int64(sec), int64(nsec)), b[9:], nil
+	}
+	return nil, b, errors.New("fluent: unsupported msgpack type")
+}
+
+// decodeLen reads a 1, 2 or 4 byte length (size class 0, 1, 2).
+func decodeLen(class byte, b []byte) (int, []byte, error) {
+	switch class {
+	case 0:
+		if len(b) < 1 {
+			return 0, b, errShort
+		}
+		return int(b[0]), b[1:], nil
+	case 1:
+		if len(b) < 2 {
+			return 0, b, errShort
+		}
+		return int(binary.BigEndian.Uint16(b)), b[2:], nil
+	default:
+		if len(b) < 4 {
+			return 0, b, errShort
+		}
+		return int(binary.BigEndian.Uint32(b)), b[4:], nil
+	}
+}
+
+func decodeInt(c byte, b []byte) (any, []byte, error) {
+	size := 1 << ((c - 0xcc) & 3) // cc..cf and d0..d3 are 1, 2, 4, 8 bytes
+	if len(b) < size {
+		return nil, b, errShort
+	}
+	var v int64
+	switch c {
+	case 0xcc:
+		v = int64(b[0])
+	case 0xcd:
+		v = int64(binary.BigEndian.Uint16(b))
+	case 0xce:
+		v = int64(binary.BigEndian.Uint32(b))
+	case 0xcf:
+		v = int64(binary.BigEndian.Uint64(b))
+	case 0xd0:
+		v = int64(int8(b[0]))
+	case 0xd1:
+		v = int64(int16(binary.BigEndian.Uint16(b)))
+	case 0xd2:
+		v = int64(int32(binary.BigEndian.Uint32(b)))
+	case 0xd3:
+		v = int64(binary.BigEndian.Uint64(b))
+	}
+	return v, b[size:], nil
+}
+
+func decodeStr(b []byte, n int) (any, []byte, error) {
+	if len(b) < n {
+		return nil, b, errShort
+	}
+	return string(b[:n]), b[n:], nil
+}
+
+func decodeArray(b []byte, n int) (any, []byte, error) {
+	out := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		v, rest, err := decode(b)
+		if err != nil {
+			return nil, b, err
+		}
+		out = append(out, v)
+		b = rest
+	}
+	return out, b, nil
+}
+
+func decodeMap(b []byte, n int) (any, []byte, error) {
+	out := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		k, rest, err := decode(b)
+		if err != nil {
+			return nil, b, err
+		}
+		v, rest, err := decode(rest)
+		if err != nil {
+			return nil, b, err
+		}
+		ks, _ := k.(string)
+		out[ks] = v
+		b = rest
+	}
+	return out, b, nil
+}
@@ -0,0 +1,308 @@
+// Package fluent sends logs records to Fluentd / Fluent Bit using the
+// Forward protocol (PackedForward mode, optional at-least-once acks).
+package fluent
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zxysilent/logs/internal/batch"
+	"github.com/zxysilent/logs/internal/logfmt"
+)
+
+const (
+	defTag      = "logs"
+	defDepth    = 1
+	defBatch    = 256
+	defInterval = time.Second
+	defBuffer   = 64 * 1024 // max buffered records
+	defTimeout  = 5 * time.Second
+)
+
+var _ io.WriteCloser = (*Writer)(nil)
+
+// Writer buffers records per tag and ships them as PackedForward messages.
+type Writer struct {
+	network string
+	addr    string
+	tag     string
+	depth   int  // number of trace segments appended to tag
+	ack     bool // request and wait for server acks
+	batch   int
+	buffer  int
+	timeout time.Duration
+	entries map[string][]byte // tag → concatenated msgpack [time, record] entries
+	counts  map[string]int
+	total   int
+	dropped uint64
+	conn    net.Conn // used by flush only
+	loop    *batch.Loop
+	mu      sync.Mutex
+}
+
+// New creates a Writer sending to addr ("host:port", or "unix:///path").
+func New(addr string) *Writer {
+	w := &Writer{
+		network: "tcp",
+		addr:    addr,
+		tag:     defTag,
+		depth:   defDepth,
+		batch:   defBatch,
+		buffer:  defBuffer,
+		timeout: defTimeout,
+		entries: make(map[string][]byte),
+		counts:  make(map[string]int),
+	}
+	if strings.HasPrefix(addr, "unix://") {
+		w.network, w.addr = "unix", strings.TrimPrefix(addr, "unix://")
+	}
+	w.loop = batch.New(defInterval, w.flush)
+	return w
+}
+
+// SetTag sets the base tag (default "logs").
+func (w *Writer) SetTag(tag string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tag = tag
+}
+
+// SetTagDepth sets how many leading trace segments are appended to the tag
+// (default 1: trace=api.x1 → tag logs.api). Zero uses the base tag only,
+// which suits traces that start with a per-request id.
+func (w *Writer) SetTagDepth(n int) {
+	if n < 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.depth = n
+}
+
+// SetAck sets whether each message requests an ack and waits for it.
+func (w *Writer) SetAck(b bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ack = b
+}
+
+// SetBatch sets the number of buffered records that triggers an immediate send.
+func (w *Writer) SetBatch(n int) {
+	if n < 1 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batch = n
+}
+
+// SetBuffer sets the maximum number of buffered records kept while the server is unreachable.
+func (w *Writer) SetBuffer(n int) {
+	if n < 1 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buffer = n
+}
+
+// SetInterval sets the maximum time records wait before being sent.
+func (w *Writer) SetInterval(d time.Duration) {
+	w.loop.SetInterval(d)
+}
+
+// Dropped returns the number of records dropped because the buffer was full.
+func (w *Writer) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Write decodes one record and buffers it as a [time, record] entry.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.loop.Closed() {
+		return 0, errors.New("fluent: writer closed")
+	}
+	r := logfmt.Decode(p)
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	w.mu.Lock()
+	if w.total >= w.buffer {
+		w.mu.Unlock()
+		atomic.AddUint64(&w.dropped, 1)
+		return len(p), nil
+	}
+	tag := w.tagOf(r.Trace)
+	w.entries[tag] = appendEntry(w.entries[tag], r)
+	w.counts[tag]++
+	w.total++
+	full := w.total >= w.batch
+	w.mu.Unlock()
+	if full {
+		w.loop.Kick()
+	}
+	return len(p), nil
+}
+
+// tagOf derives the Fluentd tag from the trace namespace; the caller holds w.mu.
+func (w *Writer) tagOf(trace string) string {
+	if w.depth == 0 || trace == "" {
+		return w.tag
+	}
+	segs := strings.SplitN(trace, ".", w.depth+1)
+	if len(segs) > w.depth {
+		segs = segs[:w.depth]
+	}
+	return w.tag + "." + strings.Join(segs, ".")
+}
+
+// appendEntry appends the msgpack [time, record] pair for r.
+func appendEntry(b []byte, r logfmt.Record) []byte {
+	n := 2 + len(r.Fields)
+	if r.Trace != "" {
+		n++
+	}
+	if r.Caller != "" {
+		n++
+	}
+	b = appendArray(b, 2)
+	b = appendEventTime(b, r.Time)
+	b = appendMap(b, n)
+	b = appendString(appendString(b, logfmt.LevelKey), r.Level)
+	if r.Trace != "" {
+		b = appendString(appendString(b, logfmt.TraceKey), r.Trace)
+	}
+	if r.Caller != "" {
+		b = appendString(appendString(b, logfmt.CallerKey), r.Caller)
+	}
+	for _, f := range r.Fields {
+		b = appendValue(appendString(b, f.Key), f.Value())
+	}
+	return appendString(appendString(b, logfmt.MesgKey), r.Msg)
+}
+
+// Flush sends all buffered records. Tags that fail are kept for the next
+// attempt and the connection is dropped so that it is redialed.
+func (w *Writer) Flush() error {
+	return w.loop.Flush()
+}
+
+// flush implements Flush; the loop serializes calls.
+func (w *Writer) flush() error {
+	w.mu.Lock()
+	entries, counts := w.entries, w.counts
+	w.entries, w.counts, w.total = make(map[string][]byte), make(map[string]int), 0
+	ack := w.ack
+	w.mu.Unlock()
+	var err error
+	for tag, packed := range entries {
+		if err == nil {
+			err = w.send(tag, packed, counts[tag], ack)
+			if err == nil {
+				continue
+			}
+			w.closeConn()
+		}
+		w.requeue(tag, packed, counts[tag])
+	}
+	return err
+}
+
+// requeue puts an unsent tag batch back, dropping it if the buffer is full.
+func (w *Writer) requeue(tag string, packed []byte, count int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.total+count > w.buffer {
+		atomic.AddUint64(&w.dropped, uint64(count))
+		return
+	}
+	w.entries[tag] = append(packed, w.entries[tag]...)
+	w.counts[tag] += count
+	w.total += count
+}
+
+// send writes one PackedForward message and waits for its ack if requested.
+// A write error on a reused connection usually means the server closed it,
+// so the message is retried once on a fresh connection.
+func (w *Writer) send(tag string, packed []byte, count int, ack bool) error {
+	msg := appendArray(make([]byte, 0, len(packed)+64), 3)
+	msg = appendString(msg, tag)
+	msg = appendBin(msg, packed)
+	var chunk string
+	if ack {
+		var id [16]byte
+		rand.Read(id[:])
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+		msg = appendMap(msg, 2)
+		msg = appendInt(appendString(msg, "size"), int64(count))
+		msg = appendString(appendString(msg, "chunk"), chunk)
+	} else {
+		msg = appendMap(msg, 1)
+		msg = appendInt(appendString(msg, "size"), int64(count))
+	}
+	reused := w.conn != nil
+	err := w.write(msg)
+	if err != nil && reused {
+		w.closeConn()
+		err = w.write(msg)
+	}
+	if err != nil || !ack {
+		return err
+	}
+	return w.readAck(chunk)
+}
+
+// write sends msg, dialing first if there is no connection.
+func (w *Writer) write(msg []byte) error {
+	if w.conn == nil {
+		conn, err := net.DialTimeout(w.network, w.addr, w.timeout)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	_, err := w.conn.Write(msg)
+	return err
+}
+
+// closeConn drops the connection so that the next send redials.
+func (w *Writer) closeConn() {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// readAck reads the server response and checks it acknowledges chunk.
+func (w *Writer) readAck(chunk string) error {
+	w.conn.SetReadDeadline(time.Now().Add(w.timeout))
+	buf := make([]byte, 0, 64)
+	tmp := make([]byte, 64)
+	for {
+		n, err := w.conn.Read(tmp)
+		buf = append(buf, tmp[:n]...)
+		if v, _, derr := decode(buf); derr == nil {
+			if m, ok := v.(map[string]any); ok && m["ack"] == chunk {
+				return nil
+			}
+			return errors.New("fluent: ack mismatch")
+		} else if derr != errShort {
+			return derr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Close stops the background sender, flushes buffered records and closes the connection.
+func (w *Writer) Close() error {
+	return w.loop.Close(w.closeConn)
+}
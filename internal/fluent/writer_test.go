@@ -0,0 +1,190 @@
+package fluent
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// server is a local Forward protocol receiver that decodes msgpack messages.
+type server struct {
+	ln   net.Listener
+	ack  bool
+	once bool // close each connection after one message (forces redial)
+	mu   sync.Mutex
+	msgs [][]any
+}
+
+func newServer(t *testing.T, ack, once bool) *server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &server{ln: ln, ack: ack, once: once}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	var buf []byte
+	tmp := make([]byte, 4096)
+	for {
+		n, err := conn.Read(tmp)
+		buf = append(buf, tmp[:n]...)
+		for len(buf) > 0 {
+			v, rest, derr := decode(buf)
+			if derr != nil {
+				break
+			}
+			buf = rest
+			msg := v.([]any)
+			s.mu.Lock()
+			s.msgs = append(s.msgs, msg)
+			s.mu.Unlock()
+			if s.ack {
+				opt := msg[2].(map[string]any)
+				conn.Write(appendString(appendString(appendMap(nil, 1), "ack"), opt["chunk"].(string)))
+			}
+			if s.once {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *server) messages() [][]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]any(nil), s.msgs...)
+}
+
+// entries decodes the PackedForward entries of a message.
+func entries(t *testing.T, msg []any) [][]any {
+	packed := msg[1].([]byte)
+	var out [][]any
+	for len(packed) > 0 {
+		v, rest, err := decode(packed)
+		if err != nil {
+			t.Fatalf("decode entry: %v", err)
+		}
+		out = append(out, v.([]any))
+		packed = rest
+	}
+	return out
+}
+
+// TestWriterPackedForward verifies records arrive as tagged maps with EventTime and acks.
+func TestWriterPackedForward(t *testing.T) {
+	s := newServer(t, true, false)
+	w := New(s.ln.Addr().String())
+	w.SetAck(true)
+	w.Write([]byte(`time=2026-05-09T10:11:12.345 level=INF trace=api.x1 caller=/main.go:42 user=alice n=3 msg="hello world"` + "\n"))
+	w.Write([]byte(`time=2026-05-09T10:11:13.000 level=ERR trace=api.x2 ok=false msg=second` + "\n"))
+	w.Write([]byte(`time=2026-05-09T10:11:14.000 level=WRN trace=db msg=third` + "\n"))
+	if err := w.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	w.Close()
+
+	byTag := map[string][][]any{}
+	for _, msg := range s.messages() {
+		byTag[msg[0].(string)] = append(byTag[msg[0].(string)], entries(t, msg)...)
+	}
+	if len(byTag["logs.api"]) != 2 || len(byTag["logs.db"]) != 1 {
+		t.Fatalf("tag grouping mismatch: %v", byTag)
+	}
+	first := byTag["logs.api"][0]
+	ts := first[0].(time.Time)
+	if want := time.Date(2026, 5, 9, 10, 11, 12, 345e6, time.Local); !ts.Equal(want) {
+		t.Fatalf("event time mismatch: %v", ts)
+	}
+	want := map[string]any{
+		"level": "INF", "trace": "api.x1", "caller": "/main.go:42",
+		"user": "alice", "n": int64(3), "msg": "hello world",
+	}
+	if got := first[1].(map[string]any); !reflect.DeepEqual(got, want) {
+		t.Fatalf("record mismatch:\n got %v\nwant %v", got, want)
+	}
+	if got := byTag["logs.api"][1][1].(map[string]any)["ok"]; got != false {
+		t.Fatalf("bool field mismatch: %v", got)
+	}
+}
+
+// TestWriterReconnect verifies acked records survive a dropped connection.
+func TestWriterReconnect(t *testing.T) {
+	s := newServer(t, true, true)
+	w := New(s.ln.Addr().String())
+	defer w.Close()
+	w.SetAck(true)
+	for i := 0; i < 3; i++ {
+		w.Write([]byte("level=INF msg=x\n"))
+		// the server closes after each message; the write to the stale conn
+		// or the missing ack fails the send, and the Writer redials.
+		for j := 0; j < 20; j++ {
+			if w.Flush() == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.messages()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(s.messages()); n < 3 {
+		t.Fatalf("want at least 3 messages after reconnects, got %d", n)
+	}
+}
+
+// TestWriterBufferLimit verifies records beyond the buffer are dropped while the server is down.
+func TestWriterBufferLimit(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+	w := New(addr)
+	defer w.Close()
+	w.SetBuffer(2)
+	w.SetBatch(100)
+	for i := 0; i < 3; i++ {
+		w.Write([]byte("level=INF msg=x\n"))
+	}
+	if err := w.Flush(); err == nil {
+		t.Fatal("expected dial error")
+	}
+	if w.Dropped() != 1 {
+		t.Fatalf("want 1 dropped record, got %d", w.Dropped())
+	}
+}
+
+// TestTagOf verifies the tag takes the configured number of trace segments.
+func TestTagOf(t *testing.T) {
+	for depth, cases := range map[int]map[string]string{
+		2: {"": "app", "api": "app.api", "api.v1.x": "app.api.v1"},
+		0: {"": "app", "api.v1.x": "app"},
+	} {
+		w := &Writer{tag: "app", depth: depth}
+		for in, want := range cases {
+			if got := w.tagOf(in); got != want {
+				t.Fatalf("depth %d: tagOf(%q)=%q, want %q", depth, in, got, want)
+			}
+		}
+	}
+}
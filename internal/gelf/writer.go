@@ -0,0 +1,339 @@
+// Package gelf sends logs records to Graylog as GELF 1.1 messages over UDP
+// (compressed and chunked) or TCP (null-byte delimited).
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zxysilent/logs/internal/logfmt"
+)
+
+const (
+	defChunkSize = 1420 // safe datagram payload for most networks
+	minChunkSize = 512
+	maxChunks    = 128
+	chunkHeader  = 12 // magic(2) + id(8) + seq(1) + count(1)
+	defTimeout   = 5 * time.Second
+	defQueue     = 1024 // messages waiting to be sent
+	defRetry     = time.Second
+)
+
+// Compression kinds for UDP payloads.
+const (
+	CompressGzip = "gzip"
+	CompressZlib = "zlib"
+	CompressNone = "none"
+)
+
+var (
+	errTooLarge = errors.New("gelf: message exceeds 128 chunks")
+	errDown     = errors.New("gelf: server unreachable")
+)
+
+var _ io.WriteCloser = (*Writer)(nil)
+
+// Writer encodes each record as GELF JSON and queues it; a background
+// goroutine sends the queue, so Write never waits for the network.
+type Writer struct {
+	network  string // udp or tcp
+	addr     string
+	host     string
+	compress string
+	chunk    int
+	queue    chan [][]byte // datagrams (UDP) or one delimited message (TCP) per record
+	dropped  uint64        // records dropped because the queue was full or sending failed
+	conn     net.Conn      // used by the sender only
+	retryAt  time.Time     // no dial before, after a failed one (sender only)
+	mu       sync.Mutex    // guards settings, closed and sending on queue
+	closed   bool
+	exited   chan struct{}
+}
+
+// New creates a Writer for addr: "udp://host:port", "tcp://host:port" or
+// a bare "host:port" (UDP).
+func New(addr string) *Writer {
+	w := &Writer{
+		network:  "udp",
+		addr:     addr,
+		compress: CompressGzip,
+		chunk:    defChunkSize,
+		queue:    make(chan [][]byte, defQueue),
+		exited:   make(chan struct{}),
+	}
+	if i := strings.Index(addr, "://"); i >= 0 {
+		w.network, w.addr = addr[:i], addr[i+3:]
+	}
+	w.host, _ = os.Hostname()
+	go w.sender()
+	return w
+}
+
+// SetHost sets the GELF host field (default os.Hostname).
+func (w *Writer) SetHost(host string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.host = host
+}
+
+// SetCompress sets the UDP payload compression: gzip (default), zlib or none.
+// TCP payloads are never compressed.
+func (w *Writer) SetCompress(kind string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch kind {
+	case CompressGzip, CompressZlib, CompressNone:
+		w.compress = kind
+	}
+}
+
+// SetChunkSize sets the maximum UDP datagram size including the chunk header.
+func (w *Writer) SetChunkSize(n int) {
+	if n < minChunkSize {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.chunk = n
+}
+
+// Dropped returns the number of records dropped because the queue was full
+// or sending failed.
+func (w *Writer) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Write converts one record to GELF and queues it for sending. When the
+// queue is full the record is dropped and counted (see Dropped).
+func (w *Writer) Write(p []byte) (int, error) {
+	r := logfmt.Decode(p)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	msg, err := json.Marshal(convert(r, w.host))
+	if err != nil {
+		return 0, err
+	}
+	var dgrams [][]byte
+	if w.network == "tcp" {
+		dgrams = [][]byte{append(msg, 0)}
+	} else if dgrams, err = w.datagrams(msg); err != nil {
+		return 0, err
+	}
+	select {
+	case w.queue <- dgrams:
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// sender sends queued messages until the queue is closed.
+func (w *Writer) sender() {
+	defer close(w.exited)
+	for dgrams := range w.queue {
+		if err := w.send(dgrams); err != nil {
+			atomic.AddUint64(&w.dropped, 1)
+		}
+	}
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// send writes one message, dialing first if there is no connection. A
+// write error on a reused TCP connection usually means the server closed
+// it, so the message is retried once on a fresh connection. After a failed
+// dial, messages are dropped without dialing for defRetry, so that an
+// unreachable server does not hold up the queue (or Close).
+func (w *Writer) send(dgrams [][]byte) error {
+	reused := w.conn != nil
+	err := w.write(dgrams)
+	if err != nil && reused && w.network == "tcp" {
+		err = w.write(dgrams)
+	}
+	return err
+}
+
+func (w *Writer) write(dgrams [][]byte) error {
+	if w.conn == nil {
+		if time.Now().Before(w.retryAt) {
+			return errDown
+		}
+		conn, err := net.DialTimeout(w.network, w.addr, defTimeout)
+		if err != nil {
+			w.retryAt = time.Now().Add(defRetry)
+			return err
+		}
+		w.conn = conn
+	}
+	w.conn.SetWriteDeadline(time.Now().Add(defTimeout))
+	for _, d := range dgrams {
+		if _, err := w.conn.Write(d); err != nil {
+			// drop the connection so that the next message redials
+			w.conn.Close()
+			w.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// datagrams compresses msg and splits it into GELF chunks when it exceeds
+// the chunk size.
+func (w *Writer) datagrams(msg []byte) ([][]byte, error) {
+	payload, err := w.pack(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) <= w.chunk {
+		return [][]byte{payload}, nil
+	}
+	size := w.chunk - chunkHeader
+	count := (len(payload) + size - 1) / size
+	if count > maxChunks {
+		return nil, errTooLarge
+	}
+	var id [8]byte
+	rand.Read(id[:])
+	dgrams := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * size
+		if end > len(payload) {
+			end = len(payload)
+		}
+		d := make([]byte, 0, chunkHeader+end-seq*size)
+		d = append(d, 0x1e, 0x0f)
+		d = append(d, id[:]...)
+		d = append(d, byte(seq), byte(count))
+		dgrams = append(dgrams, append(d, payload[seq*size:end]...))
+	}
+	return dgrams, nil
+}
+
+// pack compresses msg according to the configured kind.
+func (w *Writer) pack(msg []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var zw io.WriteCloser
+	switch w.compress {
+	case CompressGzip:
+		zw = gzip.NewWriter(&buf)
+	case CompressZlib:
+		zw = zlib.NewWriter(&buf)
+	default:
+		return msg, nil
+	}
+	if _, err := zw.Write(msg); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Close sends the queued messages and closes the connection.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+	<-w.exited
+	return nil
+}
+
+// convert maps a decoded record onto the GELF 1.1 payload.
+func convert(r logfmt.Record, host string) map[string]any {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	msg := r.Msg
+	if msg == "" {
+		msg = "-" // short_message must not be empty
+	}
+	m := map[string]any{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": msg,
+		"timestamp":     float64(r.Time.UnixMilli()) / 1e3,
+		"level":         syslogLevel(r.Level),
+	}
+	if r.Caller != "" {
+		file := r.Caller
+		if idx := strings.LastIndexByte(r.Caller, ':'); idx >= 0 {
+			file = r.Caller[:idx]
+			if line, err := strconv.Atoi(r.Caller[idx+1:]); err == nil {
+				m["line"] = line
+			}
+		}
+		m["file"] = file
+	}
+	if r.Trace != "" {
+		m["_trace"] = r.Trace
+	}
+	for _, f := range r.Fields {
+		var v any
+		switch fv := f.Value().(type) {
+		case nil:
+			continue
+		case bool:
+			v = strconv.FormatBool(fv)
+		default:
+			v = fv
+		}
+		m[fieldName(f.Key)] = v
+	}
+	return m
+}
+
+// syslogLevel maps a short level name to its syslog severity.
+func syslogLevel(name string) int {
+	switch name {
+	case "DBG":
+		return 7
+	case "WRN":
+		return 4
+	case "ERR":
+		return 3
+	default:
+		return 6
+	}
+}
+
+// fieldName builds an additional field name: "_" + key restricted to [\w.-];
+// the reserved "_id" becomes "_id_".
+func fieldName(key string) string {
+	b := make([]byte, 0, len(key)+2)
+	b = append(b, '_')
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c == '_' || c == '.' || c == '-' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
+			b = append(b, c)
+		} else {
+			b = append(b, '_')
+		}
+	}
+	if string(b) == "_id" {
+		b = append(b, '_')
+	}
+	return string(b)
+}
@@ -0,0 +1,238 @@
+package gelf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// receive reads datagrams from pc until a full GELF message is reassembled.
+// n, when non-nil, receives the number of datagrams read.
+func receive(t *testing.T, pc net.PacketConn, n ...*int) []byte {
+	t.Helper()
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	chunks := map[byte][]byte{}
+	buf := make([]byte, 65536)
+	for {
+		size, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if len(n) > 0 {
+			*n[0]++
+		}
+		d := append([]byte(nil), buf[:size]...)
+		if len(d) < 2 || d[0] != 0x1e || d[1] != 0x0f {
+			return d
+		}
+		seq, count := d[10], d[11]
+		chunks[seq] = d[12:]
+		if len(chunks) == int(count) {
+			var out []byte
+			for i := byte(0); i < count; i++ {
+				out = append(out, chunks[i]...)
+			}
+			return out
+		}
+	}
+}
+
+func inflate(t *testing.T, p []byte) map[string]any {
+	t.Helper()
+	var r io.Reader = bytes.NewReader(p)
+	switch {
+	case len(p) > 1 && p[0] == 0x1f && p[1] == 0x8b:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatalf("gzip: %v", err)
+		}
+		r = gr
+	case len(p) > 0 && p[0] == 0x78:
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			t.Fatalf("zlib: %v", err)
+		}
+		r = zr
+	}
+	var m map[string]any
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return m
+}
+
+func listenUDP(t *testing.T) net.PacketConn {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	return pc
+}
+
+// TestWriterUDP verifies field mapping over an unchunked gzip datagram.
+func TestWriterUDP(t *testing.T) {
+	pc := listenUDP(t)
+	w := New(pc.LocalAddr().String())
+	defer w.Close()
+	w.SetHost("h1")
+	if _, err := w.Write([]byte(`time=2026-05-09T10:11:12.345 level=ERR trace=api caller=/main.go:42 id=7 n=3 ok=true "a b"=x error=nil msg="boom now"` + "\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	m := inflate(t, receive(t, pc))
+	want := map[string]any{
+		"version": "1.1", "host": "h1", "short_message": "boom now",
+		"level": 3.0, "file": "/main.go", "line": 42.0, "_trace": "api",
+		"_id_": 7.0, "_n": 3.0, "_ok": "true", "_a_b": "x",
+	}
+	for k, v := range want {
+		if m[k] != v {
+			t.Fatalf("%s = %#v, want %#v (all: %v)", k, m[k], v, m)
+		}
+	}
+	if _, ok := m["_error"]; ok {
+		t.Fatalf("nil field should be omitted: %v", m)
+	}
+	if ts := time.Date(2026, 5, 9, 10, 11, 12, 345e6, time.Local); m["timestamp"] != float64(ts.UnixMilli())/1e3 {
+		t.Fatalf("timestamp mismatch: %v", m["timestamp"])
+	}
+}
+
+// TestWriterUDPChunked verifies large payloads are chunked and reassemble correctly.
+func TestWriterUDPChunked(t *testing.T) {
+	for _, kind := range []string{CompressNone, CompressZlib, CompressGzip} {
+		pc := listenUDP(t)
+		w := New("udp://" + pc.LocalAddr().String())
+		w.SetCompress(kind)
+		w.SetChunkSize(minChunkSize)
+		// random hex still exceeds several chunks after compression
+		raw := make([]byte, 2048)
+		rand.Read(raw)
+		big := hex.EncodeToString(raw)
+		chunks := 0
+		if _, err := w.Write([]byte("level=INF big=" + big + " msg=large\n")); err != nil {
+			t.Fatalf("%s write: %v", kind, err)
+		}
+		m := inflate(t, receive(t, pc, &chunks))
+		if chunks < 2 {
+			t.Fatalf("%s: expected a chunked message", kind)
+		}
+		if m["_big"] != big || m["short_message"] != "large" {
+			t.Fatalf("%s: reassembled message mismatch", kind)
+		}
+		w.Close()
+	}
+}
+
+// TestWriterUDPTooLarge verifies messages over 128 chunks are rejected.
+func TestWriterUDPTooLarge(t *testing.T) {
+	pc := listenUDP(t)
+	w := New(pc.LocalAddr().String())
+	defer w.Close()
+	w.SetCompress(CompressNone)
+	w.SetChunkSize(minChunkSize)
+	if _, err := w.Write([]byte("level=INF big=" + strings.Repeat("x", 128*minChunkSize) + "\n")); err != errTooLarge {
+		t.Fatalf("want errTooLarge, got %v", err)
+	}
+}
+
+// TestWriterTCP verifies null-byte delimited messages over TCP.
+func TestWriterTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	got := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var msgs []string
+		r := bufio.NewReader(conn)
+		for len(msgs) < 2 {
+			s, err := r.ReadString(0)
+			if err != nil {
+				break
+			}
+			msgs = append(msgs, strings.TrimSuffix(s, "\x00"))
+		}
+		got <- msgs
+	}()
+	w := New("tcp://" + ln.Addr().String())
+	defer w.Close()
+	w.Write([]byte("level=INF msg=one\n"))
+	w.Write([]byte("level=WRN msg=two\n"))
+	msgs := <-got
+	if len(msgs) != 2 {
+		t.Fatalf("want 2 messages, got %d", len(msgs))
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(msgs[1]), &m); err != nil {
+		t.Fatalf("tcp payload must be plain JSON: %v", err)
+	}
+	if m["short_message"] != "two" || m["level"] != 4.0 {
+		t.Fatalf("tcp message mismatch: %v", m)
+	}
+}
+
+// TestWriterQueueFull verifies records beyond the queue are dropped and counted.
+func TestWriterQueueFull(t *testing.T) {
+	pc := listenUDP(t)
+	// no sender yet, so the queue fills up
+	w := &Writer{network: "udp", addr: pc.LocalAddr().String(), compress: CompressNone, chunk: defChunkSize,
+		queue: make(chan [][]byte, 1), exited: make(chan struct{})}
+	for _, msg := range []string{"a", "b", "c"} {
+		if _, err := w.Write([]byte("level=INF msg=" + msg + "\n")); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if w.Dropped() != 2 {
+		t.Fatalf("want 2 dropped, got %d", w.Dropped())
+	}
+	go w.sender()
+	w.Close()
+	if m := inflate(t, receive(t, pc)); m["short_message"] != "a" {
+		t.Fatalf("queued message mismatch: %v", m)
+	}
+}
+
+// TestWriterUnreachable verifies Write does not wait for an unreachable server.
+func TestWriterUnreachable(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+	w := New("tcp://" + addr)
+	for i := 0; i < 3; i++ {
+		if _, err := w.Write([]byte("level=INF msg=x\n")); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	w.Close()
+	if w.Dropped() != 3 {
+		t.Fatalf("want 3 dropped, got %d", w.Dropped())
+	}
+	if _, err := w.Write([]byte("level=INF msg=late\n")); err == nil {
+		t.Fatal("expected error writing after close")
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	cases := map[string]string{"user": "_user", "id": "_id_", "a b=c": "_a_b_c", "x.y-z_1": "_x.y-z_1"}
+	for in, want := range cases {
+		if got := fieldName(in); got != want {
+			t.Fatalf("fieldName(%q)=%q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// Package logfmt decodes records produced by zxysilent/logs back into
+// structured form so that sinks can re-encode them for other protocols.
+package logfmt
+
+import (
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Built-in field names written by the logs encoder.
+const (
+	TimeKey   = "time"
+	LevelKey  = "level"
+	TraceKey  = "trace"
+	CallerKey = "caller"
+	MesgKey   = "msg"
+)
+
+// TimeLayout is the layout written by textenc.PutTime (local time, no zone).
+const TimeLayout = "2006-01-02T15:04:05.000"
+
+// Field is a decoded key/value pair.
+type Field struct {
+	Key    string
+	Val    string
+	Quoted bool // value was wrapped in double quotes
+}
+
+// Record is a decoded log record. Built-in keys are split out of Fields.
+type Record struct {
+	Time   time.Time
+	Level  string // short level name: DBG, INF, WRN, ERR
+	Trace  string
+	Caller string
+	Msg    string
+	Fields []Field // custom fields in output order
+}
+
+// Decode parses one line written by logs into a Record.
+func Decode(line []byte) Record {
+	var r Record
+	for _, f := range Parse(line) {
+		switch f.Key {
+		case TimeKey:
+			if t, err := time.ParseInLocation(TimeLayout, f.Val, time.Local); err == nil {
+				r.Time = t
+				continue
+			}
+		case LevelKey:
+			r.Level = f.Val
+			continue
+		case TraceKey:
+			r.Trace = f.Val
+			continue
+		case CallerKey:
+			r.Caller = f.Val
+			continue
+		case MesgKey:
+			r.Msg = f.Val
+			continue
+		}
+		r.Fields = append(r.Fields, f)
+	}
+	return r
+}
+
+// Level returns the slog-aligned numeric value of a short level name.
+// Unknown names map to info (0).
+func Level(name string) int {
+	switch name {
+	case "DBG":
+		return -4
+	case "WRN":
+		return 4
+	case "ERR":
+		return 8
+	default:
+		return 0
+	}
+}
+
+// Value returns the typed value of a bare field: nil for nil/null,
+// bool, int64 or float64 when the text parses as one, otherwise the string.
+// Quoted values are always strings.
+func (f Field) Value() any {
+	if f.Quoted {
+		return f.Val
+	}
+	switch f.Val {
+	case "nil", "null":
+		return nil
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.ParseInt(f.Val, 10, 64); err == nil {
+		return i
+	}
+	if fl, err := strconv.ParseFloat(f.Val, 64); err == nil {
+		return fl
+	}
+	return f.Val
+}
+
+// Parse splits a logfmt line into fields, unescaping values.
+// Bare values that start with '{' or '[' are scanned as balanced JSON so
+// that Any values containing spaces stay in one field.
+func Parse(line []byte) []Field {
+	fields := make([]Field, 0, 8)
+	i, n := 0, len(line)
+	for i < n {
+		for i < n && (line[i] == ' ' || line[i] == '\n' || line[i] == '\r') {
+			i++
+		}
+		if i >= n {
+			break
+		}
+		var f Field
+		if line[i] == '"' {
+			f.Key, i = quoted(line, i)
+		} else {
+			start := i
+			for i < n && line[i] != '=' && line[i] != ' ' && line[i] != '\n' {
+				i++
+			}
+			f.Key = unescape(line[start:i])
+		}
+		if i >= n || line[i] != '=' {
+			fields = append(fields, f)
+			continue
+		}
+		i++ // '='
+		switch {
+		case i < n && line[i] == '"':
+			f.Val, i = quoted(line, i)
+			f.Quoted = true
+		case i < n && (line[i] == '{' || line[i] == '['):
+			if end := balanced(line, i); end > i {
+				f.Val = string(line[i:end])
+				i = end
+				break
+			}
+			fallthrough
+		default:
+			start := i
+			for i < n && line[i] != ' ' && line[i] != '\n' {
+				i++
+			}
+			f.Val = unescape(line[start:i])
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// quoted decodes a double-quoted token starting at line[i] and returns the
+// value and the index just past the closing quote.
+func quoted(line []byte, i int) (string, int) {
+	i++ // opening quote
+	start := i
+	for i < len(line) {
+		switch line[i] {
+		case '\\':
+			i += 2
+			continue
+		case '"':
+			return unescape(line[start:i]), i + 1
+		}
+		i++
+	}
+	if i > len(line) {
+		i = len(line)
+	}
+	return unescape(line[start:i]), i
+}
+
+// balanced returns the index just past the JSON value starting at line[i],
+// or -1 if the brackets are not balanced before the end of the line.
+func balanced(line []byte, i int) int {
+	depth := 0
+	instr := false
+	for ; i < len(line); i++ {
+		c := line[i]
+		if instr {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				instr = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			instr = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+// unescape reverses the escaping applied by textenc.quoteString.
+func unescape(b []byte) string {
+	esc := false
+	for _, c := range b {
+		if c == '\\' {
+			esc = true
+			break
+		}
+	}
+	if !esc {
+		return string(b)
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if c != '\\' || i+1 >= len(b) {
+			out = append(out, c)
+			continue
+		}
+		i++
+		switch b[i] {
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'u':
+			if i+4 < len(b) {
+				if r, err := strconv.ParseUint(string(b[i+1:i+5]), 16, 32); err == nil {
+					out = utf8.AppendRune(out, rune(r))
+					i += 4
+					continue
+				}
+			}
+			out = append(out, '\\', 'u')
+		default:
+			out = append(out, b[i])
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,101 @@
+package logfmt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zxysilent/logs/internal/textenc"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Field
+	}{
+		{"a=1 b=x", []Field{{Key: "a", Val: "1"}, {Key: "b", Val: "x"}}},
+		{`msg="hello world"`, []Field{{Key: "msg", Val: "hello world", Quoted: true}}},
+		{`k="a \"q\" b"`, []Field{{Key: "k", Val: `a "q" b`, Quoted: true}}},
+		{`k=a\nb`, []Field{{Key: "k", Val: "a\nb"}}},
+		{`k=\u001f`, []Field{{Key: "k", Val: "\x1f"}}},
+		{`"my key"=v`, []Field{{Key: "my key", Val: "v"}}},
+		{`j={"a b":1} n=2`, []Field{{Key: "j", Val: `{"a b":1}`}, {Key: "n", Val: "2"}}},
+		{`j=[1, "x]"] n=2`, []Field{{Key: "j", Val: `[1, "x]"]`}, {Key: "n", Val: "2"}}},
+		{`j={open n=2`, []Field{{Key: "j", Val: "{open"}, {Key: "n", Val: "2"}}},
+		{"flag k=", []Field{{Key: "flag"}, {Key: "k"}}},
+		{"k=v\n", []Field{{Key: "k", Val: "v"}}},
+		{"", []Field{}},
+	}
+	for _, tt := range tests {
+		if got := Parse([]byte(tt.in)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	line := []byte(`time=2026-05-09T10:11:12.345 level=ERR trace=api.x1 caller=/main.go:42 user=alice n=3 msg="boom now"` + "\n")
+	r := Decode(line)
+	want := time.Date(2026, 5, 9, 10, 11, 12, 345e6, time.Local)
+	if !r.Time.Equal(want) {
+		t.Fatalf("time = %v, want %v", r.Time, want)
+	}
+	if r.Level != "ERR" || r.Trace != "api.x1" || r.Caller != "/main.go:42" || r.Msg != "boom now" {
+		t.Fatalf("builtin fields mismatch: %+v", r)
+	}
+	if len(r.Fields) != 2 || r.Fields[0].Key != "user" || r.Fields[1].Key != "n" {
+		t.Fatalf("custom fields mismatch: %+v", r.Fields)
+	}
+	if Level(r.Level) != 8 || Level("DBG") != -4 || Level("WRN") != 4 || Level("INF") != 0 {
+		t.Fatalf("Level mapping mismatch")
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	tests := []struct {
+		f    Field
+		want any
+	}{
+		{Field{Val: "nil"}, nil},
+		{Field{Val: "true"}, true},
+		{Field{Val: "false"}, false},
+		{Field{Val: "-12"}, int64(-12)},
+		{Field{Val: "1.5"}, 1.5},
+		{Field{Val: "abc"}, "abc"},
+		{Field{Val: "12", Quoted: true}, "12"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.Value(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Value(%+v) = %#v, want %#v", tt.f, got, tt.want)
+		}
+	}
+}
+
+// FuzzRoundTrip verifies strings encoded by textenc decode back to the original.
+func FuzzRoundTrip(f *testing.F) {
+	f.Add("hello world")
+	f.Add("a=b \"c\"\n\t\\")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, s string) {
+		if !utf8Valid(s) {
+			return
+		}
+		line := textenc.PutStringQuote(textenc.PutKeyRaw(nil, "msg"), s)
+		if s == "" || s[0] == '{' || s[0] == '[' {
+			return
+		}
+		fs := Parse(line)
+		if len(fs) != 1 || fs[0].Val != s {
+			t.Fatalf("round trip %q -> %q -> %+v", s, line, fs)
+		}
+	})
+}
+
+func utf8Valid(s string) bool {
+	for _, r := range s {
+		if r == 0xfffd {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,366 @@
+// Package notify aggregates high-severity logs records and POSTs periodic
+// summaries to a webhook (chat, alerting, ...).
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/zxysilent/logs/internal/batch"
+	"github.com/zxysilent/logs/internal/logfmt"
+)
+
+const (
+	defLevel     = 8 // ERR
+	defWindow    = time.Minute
+	defRateLimit = 10
+	defRatePer   = time.Minute
+	defTraces    = 10
+	defGroups    = 100 // max distinct groups per window
+)
+
+// DefaultTemplate renders a JSON summary; "text" suits Slack-style incoming webhooks.
+const DefaultTemplate = `{"text":{{json .Text}},"level":{{json .Level}},"msg":{{json .Msg}},"caller":{{json .Caller}},` +
+	`"count":{{.Count}},"first":{{json .First}},"last":{{json .Last}},"fields":{{json .Fields}},"traces":{{json .Traces}}}`
+
+var _ io.WriteCloser = (*Writer)(nil)
+
+// Summary is the template data for one group of records sharing msg and caller.
+type Summary struct {
+	Text   string            // one-line human readable summary
+	Level  string            // highest level seen in the group
+	Msg    string            // shared message
+	Caller string            // shared caller (may be empty)
+	Count  int               // records in the window
+	First  time.Time         // first record time
+	Last   time.Time         // last record time
+	Fields map[string]string // custom fields of the first record
+	Traces []string          // distinct trace values, capped
+}
+
+type key struct{ msg, caller string }
+
+// Writer groups records at or above a level over a window and POSTs one summary per group.
+type Writer struct {
+	url        string
+	client     *http.Client
+	header     http.Header
+	level      int
+	tmpl       *template.Template
+	rateLimit  int // POSTs allowed per ratePer, 0 = unlimited
+	ratePer    time.Duration
+	quiet      bool
+	quietFrom  time.Duration // offset since midnight
+	quietTo    time.Duration
+	groups     map[key]*Summary
+	order      []key
+	sent       []time.Time // POST times inside the rate window
+	suppressed uint64      // summaries deferred due to rate limit or quiet hours
+	now        func() time.Time
+	loop       *batch.Loop
+	mu         sync.Mutex
+}
+
+// New creates a Writer posting to url.
+func New(url string) *Writer {
+	w := &Writer{
+		url:       url,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		header:    http.Header{},
+		level:     defLevel,
+		rateLimit: defRateLimit,
+		ratePer:   defRatePer,
+		groups:    make(map[key]*Summary),
+		now:       time.Now,
+	}
+	w.header.Set("Content-Type", "application/json")
+	w.tmpl = template.Must(parse(DefaultTemplate))
+	w.loop = batch.New(defWindow, w.flush)
+	return w
+}
+
+func parse(text string) (*template.Template, error) {
+	return template.New("notify").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(text)
+}
+
+// SetLevel sets the minimum slog-aligned level that is aggregated (default 8, ERR).
+func (w *Writer) SetLevel(lv int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.level = lv
+}
+
+// SetWindow sets the aggregation window (default 1m).
+func (w *Writer) SetWindow(d time.Duration) {
+	w.loop.SetInterval(d)
+}
+
+// SetTemplate sets the text/template rendering the request body from a Summary.
+// The template may use {{json .X}} to emit JSON-encoded values.
+func (w *Writer) SetTemplate(text string) error {
+	t, err := parse(text)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tmpl = t
+	return nil
+}
+
+// SetRateLimit allows at most n POSTs per interval (default 10 per minute); n=0 disables the limit.
+func (w *Writer) SetRateLimit(n int, per time.Duration) {
+	if n < 0 || per <= 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rateLimit, w.ratePer = n, per
+}
+
+// SetQuietHours suppresses notifications between from and to (offsets since
+// local midnight); from > to wraps past midnight (e.g. 22h → 7h).
+func (w *Writer) SetQuietHours(from, to time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.quiet, w.quietFrom, w.quietTo = from != to, from, to
+}
+
+// SetHeader sets an HTTP header sent with every request.
+func (w *Writer) SetHeader(key, val string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.header.Set(key, val)
+}
+
+// SetClient sets the HTTP client.
+func (w *Writer) SetClient(c *http.Client) {
+	if c == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.client = c
+}
+
+// Suppressed returns the number of summaries held back by the rate limit or
+// quiet hours. Their records are counted in the next summary of the group.
+func (w *Writer) Suppressed() uint64 {
+	return atomic.LoadUint64(&w.suppressed)
+}
+
+// Write aggregates a record when its level reaches the threshold.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.loop.Closed() {
+		return 0, errors.New("notify: writer closed")
+	}
+	r := logfmt.Decode(p)
+	k := key{msg: r.Msg, caller: r.Caller}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if logfmt.Level(r.Level) < w.level {
+		return len(p), nil
+	}
+	if r.Time.IsZero() {
+		r.Time = w.now()
+	}
+	s, ok := w.groups[k]
+	if !ok {
+		if len(w.groups) >= defGroups {
+			atomic.AddUint64(&w.suppressed, 1)
+			return len(p), nil
+		}
+		s = &Summary{Level: r.Level, Msg: r.Msg, Caller: r.Caller, First: r.Time, Fields: map[string]string{}}
+		for _, f := range r.Fields {
+			s.Fields[f.Key] = f.Val
+		}
+		w.groups[k] = s
+		w.order = append(w.order, k)
+	}
+	s.Count++
+	s.Last = r.Time
+	if logfmt.Level(r.Level) > logfmt.Level(s.Level) {
+		s.Level = r.Level
+	}
+	addTrace(s, r.Trace)
+	return len(p), nil
+}
+
+// addTrace records a distinct trace value of the group, up to the cap.
+func addTrace(s *Summary, trace string) {
+	if trace != "" && len(s.Traces) < defTraces && !contains(s.Traces, trace) {
+		s.Traces = append(s.Traces, trace)
+	}
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush sends one summary per group collected since the last flush.
+// Groups held back by the rate limit or quiet hours are kept, and their
+// records are counted in the group's next summary; Close sends them
+// regardless.
+func (w *Writer) Flush() error {
+	return w.loop.Flush()
+}
+
+// flush implements Flush; the loop serializes calls. Once the writer is
+// closed nothing is held, so the final flush ignores the limits.
+func (w *Writer) flush() error {
+	final := w.loop.Closed()
+	w.mu.Lock()
+	groups, order := w.groups, w.order
+	w.groups, w.order = make(map[key]*Summary), nil
+	tmpl, client, header := w.tmpl, w.client, w.header.Clone()
+	w.mu.Unlock()
+	var err error
+	var held []*Summary
+	defer func() { w.hold(held) }()
+	for _, k := range order {
+		s := groups[k]
+		if !final && !w.allow() {
+			atomic.AddUint64(&w.suppressed, 1)
+			held = append(held, s)
+			continue
+		}
+		s.Text = text(s)
+		var body bytes.Buffer
+		if e := tmpl.Execute(&body, s); e != nil {
+			err = e
+			continue
+		}
+		if e := w.post(client, header, body.Bytes()); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// hold puts held summaries back in front of the groups collected since the
+// flush started, merging in the records of the same group.
+func (w *Writer) hold(held []*Summary) {
+	if len(held) == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	order := make([]key, 0, len(held)+len(w.order))
+	merged := make(map[key]bool, len(held))
+	for _, s := range held {
+		k := key{msg: s.Msg, caller: s.Caller}
+		if cur, ok := w.groups[k]; ok {
+			s.Count += cur.Count
+			s.Last = cur.Last
+			if logfmt.Level(cur.Level) > logfmt.Level(s.Level) {
+				s.Level = cur.Level
+			}
+			for _, tr := range cur.Traces {
+				addTrace(s, tr)
+			}
+		}
+		w.groups[k] = s
+		order = append(order, k)
+		merged[k] = true
+	}
+	for _, k := range w.order {
+		if !merged[k] {
+			order = append(order, k)
+		}
+	}
+	w.order = order
+}
+
+// allow applies quiet hours and the rate limit, recording the send on success.
+func (w *Writer) allow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.now()
+	if w.quiet {
+		y, m, d := now.Date()
+		off := now.Sub(time.Date(y, m, d, 0, 0, 0, 0, now.Location()))
+		in := off >= w.quietFrom && off < w.quietTo
+		if w.quietFrom > w.quietTo {
+			in = off >= w.quietFrom || off < w.quietTo
+		}
+		if in {
+			return false
+		}
+	}
+	if w.rateLimit == 0 {
+		return true
+	}
+	kept := w.sent[:0]
+	for _, t := range w.sent {
+		if now.Sub(t) < w.ratePer {
+			kept = append(kept, t)
+		}
+	}
+	w.sent = kept
+	if len(w.sent) >= w.rateLimit {
+		return false
+	}
+	w.sent = append(w.sent, now)
+	return true
+}
+
+// text builds the one-line summary.
+func text(s *Summary) string {
+	b := make([]byte, 0, 64)
+	b = append(b, '[')
+	b = append(b, s.Level...)
+	b = append(b, "] "...)
+	b = append(b, s.Msg...)
+	if s.Count > 1 {
+		b = append(b, " (x"...)
+		b = strconv.AppendInt(b, int64(s.Count), 10)
+		b = append(b, ')')
+	}
+	if s.Caller != "" {
+		b = append(b, " at "...)
+		b = append(b, s.Caller...)
+	}
+	return string(b)
+}
+
+func (w *Writer) post(client *http.Client, header http.Header, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.New("notify: unexpected status " + resp.Status)
+	}
+	return nil
+}
+
+// Close stops the window timer and sends pending summaries, including held
+// ones, ignoring the rate limit and quiet hours.
+func (w *Writer) Close() error {
+	return w.loop.Close(nil)
+}
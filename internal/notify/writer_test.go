@@ -0,0 +1,194 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type hook struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (h *hook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, _ := io.ReadAll(r.Body)
+	h.mu.Lock()
+	h.bodies = append(h.bodies, string(b))
+	h.mu.Unlock()
+}
+
+func (h *hook) got() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.bodies...)
+}
+
+// TestWriterAggregate verifies records are grouped by msg+caller and summarized once.
+func TestWriterAggregate(t *testing.T) {
+	h := &hook{}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	w := New(srv.URL)
+	defer w.Close()
+	w.Write([]byte("time=2026-05-09T10:00:00.000 level=ERR trace=a caller=/db.go:9 table=users msg=\"query failed\"\n"))
+	w.Write([]byte("time=2026-05-09T10:00:05.000 level=ERR trace=b caller=/db.go:9 table=orders msg=\"query failed\"\n"))
+	w.Write([]byte("time=2026-05-09T10:00:06.000 level=ERR trace=b caller=/db.go:9 msg=\"query failed\"\n"))
+	w.Write([]byte("time=2026-05-09T10:00:07.000 level=ERR caller=/api.go:3 msg=timeout\n"))
+	w.Write([]byte("time=2026-05-09T10:00:08.000 level=INF caller=/db.go:9 msg=\"query failed\"\n")) // below threshold
+	w.Write([]byte("caller=/db.go:9 msg=\"query failed level=ERR\"\n"))                              // level only in text
+	if err := w.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	bodies := h.got()
+	if len(bodies) != 2 {
+		t.Fatalf("want 2 summaries, got %d: %v", len(bodies), bodies)
+	}
+	var s struct {
+		Text   string
+		Count  int
+		First  time.Time
+		Last   time.Time
+		Fields map[string]string
+		Traces []string
+	}
+	if err := json.Unmarshal([]byte(bodies[0]), &s); err != nil {
+		t.Fatalf("default template must render JSON: %v\n%s", err, bodies[0])
+	}
+	if s.Count != 3 || s.Text != "[ERR] query failed (x3) at /db.go:9" {
+		t.Fatalf("summary mismatch: %+v", s)
+	}
+	if s.Last.Sub(s.First) != 6*time.Second {
+		t.Fatalf("first/last mismatch: %v %v", s.First, s.Last)
+	}
+	if s.Fields["table"] != "users" || len(s.Traces) != 2 {
+		t.Fatalf("sample fields/traces mismatch: %+v", s)
+	}
+	if err := w.Flush(); err != nil || len(h.got()) != 2 {
+		t.Fatalf("empty window should not post")
+	}
+}
+
+// TestWriterTemplate verifies a custom template and level threshold.
+func TestWriterTemplate(t *testing.T) {
+	h := &hook{}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	w := New(srv.URL)
+	defer w.Close()
+	if err := w.SetTemplate("{{.Level"); err == nil {
+		t.Fatal("expected template parse error")
+	}
+	if err := w.SetTemplate(`{"content":{{json .Text}}}`); err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	w.SetLevel(4)
+	w.Write([]byte("level=WRN msg=slow\n"))
+	w.Flush()
+	if got := h.got(); len(got) != 1 || got[0] != `{"content":"[WRN] slow"}` {
+		t.Fatalf("custom template mismatch: %v", got)
+	}
+}
+
+// TestWriterRateLimit verifies summaries beyond the rate limit are held for the next flush.
+func TestWriterRateLimit(t *testing.T) {
+	h := &hook{}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	w := New(srv.URL)
+	defer w.Close()
+	w.SetRateLimit(2, time.Hour)
+	for _, msg := range []string{"a", "b", "c"} {
+		w.Write([]byte("level=ERR msg=" + msg + "\n"))
+	}
+	w.Flush()
+	if len(h.got()) != 2 || w.Suppressed() != 1 {
+		t.Fatalf("want 2 sent 1 suppressed, got %d sent %d suppressed", len(h.got()), w.Suppressed())
+	}
+	w.Write([]byte("level=ERR msg=c\n"))
+	w.SetRateLimit(0, time.Hour)
+	w.Flush()
+	if got := h.got(); len(got) != 3 || !strings.Contains(got[2], `"text":"[ERR] c (x2)"`) {
+		t.Fatalf("held summary not carried into the next one: %v", got)
+	}
+}
+
+// TestWriterQuietHours verifies no summaries are sent inside quiet hours.
+func TestWriterQuietHours(t *testing.T) {
+	h := &hook{}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	w := New(srv.URL)
+	defer w.Close()
+	w.SetQuietHours(22*time.Hour, 7*time.Hour)
+	for _, c := range []struct {
+		hour int
+		sent bool
+	}{{23, false}, {3, false}, {7, true}, {12, true}} {
+		before := len(h.got())
+		w.mu.Lock()
+		w.now = func() time.Time { return time.Date(2026, 5, 9, c.hour, 30, 0, 0, time.Local) }
+		w.mu.Unlock()
+		w.Write([]byte("level=ERR msg=x\n"))
+		w.Flush()
+		if sent := len(h.got()) > before; sent != c.sent {
+			t.Fatalf("hour %d: sent=%v, want %v", c.hour, sent, c.sent)
+		}
+	}
+	if got := h.got(); !strings.Contains(got[0], `"count":3`) || !strings.Contains(got[1], `"count":1`) {
+		t.Fatalf("quiet hour records not carried over: %v", got)
+	}
+}
+
+// TestWriterCloseHeld verifies Close sends summaries held by the rate limit and quiet hours.
+func TestWriterCloseHeld(t *testing.T) {
+	h := &hook{}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	w := New(srv.URL)
+	w.SetRateLimit(1, time.Hour)
+	w.Write([]byte("level=ERR msg=a\n"))
+	w.Write([]byte("level=ERR msg=b\n"))
+	w.Flush()
+	w.SetQuietHours(0, 24*time.Hour)
+	w.Write([]byte("level=ERR msg=b\n"))
+	w.Write([]byte("level=ERR msg=c\n"))
+	w.Flush()
+	if len(h.got()) != 1 {
+		t.Fatalf("want 1 sent before Close, got %v", h.got())
+	}
+	w.Close()
+	got := h.got()
+	if len(got) != 3 || !strings.Contains(got[1], `"text":"[ERR] b (x2)"`) || !strings.Contains(got[2], `"text":"[ERR] c"`) {
+		t.Fatalf("held summaries not sent on Close: %v", got)
+	}
+}
+
+// TestWriterWindow verifies the window timer flushes without an explicit Flush.
+func TestWriterWindow(t *testing.T) {
+	h := &hook{}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	w := New(srv.URL)
+	defer w.Close()
+	w.SetWindow(20 * time.Millisecond)
+	w.Write([]byte("level=ERR msg=x\n"))
+	deadline := time.Now().Add(2 * time.Second)
+	for len(h.got()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(h.got()) != 1 {
+		t.Fatalf("window flush did not post")
+	}
+}
@@ -0,0 +1,324 @@
+// Package otlp exports logs records as OTLP/HTTP JSON (logs/v1) without
+// depending on the OpenTelemetry SDK.
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zxysilent/logs/internal/batch"
+	"github.com/zxysilent/logs/internal/logfmt"
+)
+
+const (
+	defBatch    = 512
+	defInterval = 5 * time.Second
+	defQueue    = 16 // queue limit in batches
+	scopeName   = "github.com/zxysilent/logs"
+)
+
+var _ io.WriteCloser = (*Writer)(nil)
+
+// Writer decodes logs records and POSTs them in batches to an OTLP/HTTP endpoint.
+type Writer struct {
+	endpoint string
+	client   *http.Client
+	header   http.Header
+	resource []keyValue
+	batch    int
+	pending  []logRecord
+	dropped  uint64           // records dropped because the queue was full
+	now      func() time.Time // observed time source
+	loop     *batch.Loop
+	mu       sync.Mutex
+}
+
+// New creates a Writer posting to endpoint (e.g. http://host:4318/v1/logs).
+func New(endpoint string) *Writer {
+	w := &Writer{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		header:   http.Header{},
+		batch:    defBatch,
+		now:      time.Now,
+	}
+	w.header.Set("Content-Type", "application/json")
+	w.loop = batch.New(defInterval, w.flush)
+	return w
+}
+
+// SetBatch sets the number of records that triggers an immediate POST.
+func (w *Writer) SetBatch(n int) {
+	if n < 1 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batch = n
+}
+
+// SetInterval sets the maximum time records wait before being POSTed.
+func (w *Writer) SetInterval(d time.Duration) {
+	w.loop.SetInterval(d)
+}
+
+// SetClock sets the source of observed times; nil restores time.Now.
+func (w *Writer) SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.now = now
+}
+
+// SetHeader sets an HTTP header sent with every request (e.g. authorization).
+func (w *Writer) SetHeader(key, val string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.header.Set(key, val)
+}
+
+// SetResource adds a resource attribute (e.g. service.name).
+func (w *Writer) SetResource(key, val string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.resource = append(w.resource, keyValue{Key: key, Value: anyValue{StringValue: &val}})
+}
+
+// SetClient sets the HTTP client used for export.
+func (w *Writer) SetClient(c *http.Client) {
+	if c == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.client = c
+}
+
+// Dropped returns the number of records dropped because the queue was full.
+func (w *Writer) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Write decodes one record and queues it for export.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.loop.Closed() {
+		return 0, errors.New("otlp: writer closed")
+	}
+	d := logfmt.Decode(p)
+	w.mu.Lock()
+	rec := convert(d, w.now())
+	if len(w.pending) >= w.batch*defQueue {
+		w.mu.Unlock()
+		atomic.AddUint64(&w.dropped, 1)
+		return len(p), nil
+	}
+	w.pending = append(w.pending, rec)
+	full := len(w.pending) >= w.batch
+	w.mu.Unlock()
+	if full {
+		w.loop.Kick()
+	}
+	return len(p), nil
+}
+
+// Flush POSTs all queued records. On failure the records are put back in
+// front of the queue (bounded by the queue limit) and the error is returned.
+func (w *Writer) Flush() error {
+	return w.loop.Flush()
+}
+
+// flush implements Flush; the loop serializes calls.
+func (w *Writer) flush() error {
+	w.mu.Lock()
+	recs := w.pending
+	w.pending = nil
+	client := w.client
+	header := w.header.Clone()
+	resource := w.resource
+	w.mu.Unlock()
+	for len(recs) > 0 {
+		n := len(recs)
+		if n > w.batch {
+			n = w.batch
+		}
+		if err := w.send(client, header, resource, recs[:n]); err != nil {
+			w.requeue(recs)
+			return err
+		}
+		recs = recs[n:]
+	}
+	return nil
+}
+
+// requeue puts unsent records back in front of the queue, dropping overflow.
+func (w *Writer) requeue(recs []logRecord) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	merged := append(recs[:len(recs):len(recs)], w.pending...)
+	if limit := w.batch * defQueue; len(merged) > limit {
+		atomic.AddUint64(&w.dropped, uint64(len(merged)-limit))
+		merged = merged[len(merged)-limit:]
+	}
+	w.pending = merged
+}
+
+func (w *Writer) send(client *http.Client, header http.Header, resource []keyValue, recs []logRecord) error {
+	body, err := json.Marshal(exportRequest{ResourceLogs: []resourceLogs{{
+		Resource:  resourceAttrs{Attributes: resource},
+		ScopeLogs: []scopeLogs{{Scope: scope{Name: scopeName}, LogRecords: recs}},
+	}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.New("otlp: unexpected status " + resp.Status)
+	}
+	return nil
+}
+
+// Close stops the background exporter and flushes queued records.
+func (w *Writer) Close() error {
+	return w.loop.Close(nil)
+}
+
+// convert maps a decoded record onto the OTLP LogRecord shape; observed is
+// the time the record was seen by the exporter.
+func convert(r logfmt.Record, observed time.Time) logRecord {
+	lv := logfmt.Level(r.Level)
+	msg := r.Msg
+	rec := logRecord{
+		TimeUnixNano:         strconv.FormatInt(r.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(observed.UnixNano(), 10),
+		SeverityNumber:       lv + 9, // slog → OTel: DEBUG=5 INFO=9 WARN=13 ERROR=17
+		SeverityText:         r.Level,
+		Body:                 anyValue{StringValue: &msg},
+	}
+	if r.Time.IsZero() {
+		rec.TimeUnixNano = rec.ObservedTimeUnixNano
+	}
+	if r.Trace != "" {
+		var id, ns string
+		rec.TraceID, rec.SpanID, id, ns = traceIDs(r.Trace)
+		if id != "" {
+			rec.Attributes = append(rec.Attributes, stringKV("trace_id", id))
+		}
+		if ns != "" {
+			rec.Attributes = append(rec.Attributes, stringKV("namespace", ns))
+		}
+	}
+	if r.Caller != "" {
+		file, line := r.Caller, ""
+		if idx := strings.LastIndexByte(r.Caller, ':'); idx >= 0 {
+			file, line = r.Caller[:idx], r.Caller[idx+1:]
+		}
+		rec.Attributes = append(rec.Attributes, stringKV("code.filepath", file))
+		if line != "" {
+			rec.Attributes = append(rec.Attributes, keyValue{Key: "code.lineno", Value: anyValue{IntValue: &line}})
+		}
+	}
+	for _, f := range r.Fields {
+		rec.Attributes = append(rec.Attributes, fieldKV(f))
+	}
+	return rec
+}
+
+// traceIDs splits a logs trace value into the OTLP trace and span ids, a
+// non-hex trace id and the namespace. A dotted segment holding a 32-hex W3C
+// trace id is used verbatim, and a following 16-hex segment becomes the span
+// id; the other segments form the namespace. Without a W3C id both ids are
+// empty, since made-up ids would not match any trace; the last segment
+// shaped like a logs.TraceId is returned as id instead. Other segments,
+// including custom ids, stay in the namespace.
+func traceIDs(trace string) (traceID, spanID, id, ns string) {
+	segs := strings.Split(trace, ".")
+	for i, seg := range segs {
+		if len(seg) != 32 || !isHex(seg) {
+			continue
+		}
+		traceID = strings.ToLower(seg)
+		rest := segs[i+1:]
+		if len(rest) > 0 && len(rest[0]) == 16 && isHex(rest[0]) {
+			spanID = strings.ToLower(rest[0])
+			rest = rest[1:]
+		}
+		return traceID, spanID, "", strings.Join(append(segs[:i:i], rest...), ".")
+	}
+	for i := len(segs) - 1; i >= 0; i-- {
+		if isGenID(segs[i]) {
+			return "", "", segs[i], strings.Join(append(segs[:i:i], segs[i+1:]...), ".")
+		}
+	}
+	return "", "", "", trace
+}
+
+// genAlphabet is the alphabet of logs.TraceId.
+const genAlphabet = "23456789abcdefghijkmnpqrstuvwxyz"
+
+// isGenID reports whether s looks like a logs.TraceId: 8 characters of its
+// alphabet, at least one of them a digit so that words are not taken for ids.
+func isGenID(s string) bool {
+	if len(s) != 8 {
+		return false
+	}
+	digit := false
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(genAlphabet, s[i]) < 0 {
+			return false
+		}
+		digit = digit || s[i] <= '9'
+	}
+	return digit
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func stringKV(key, val string) keyValue {
+	return keyValue{Key: key, Value: anyValue{StringValue: &val}}
+}
+
+// fieldKV maps a decoded field to a typed OTLP attribute.
+func fieldKV(f logfmt.Field) keyValue {
+	kv := keyValue{Key: f.Key}
+	switch v := f.Value().(type) {
+	case nil:
+	case bool:
+		kv.Value.BoolValue = &v
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &s
+	case float64:
+		kv.Value.DoubleValue = &v
+	case string:
+		kv.Value.StringValue = &v
+	}
+	return kv
+}
@@ -0,0 +1,207 @@
+package otlp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type stub struct {
+	mu   sync.Mutex
+	reqs []exportRequest
+	auth []string
+	fail bool
+}
+
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var req exportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.reqs = append(s.reqs, req)
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+}
+
+func (s *stub) records() []logRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []logRecord
+	for _, req := range s.reqs {
+		for _, rl := range req.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				out = append(out, sl.LogRecords...)
+			}
+		}
+	}
+	return out
+}
+
+func attr(rec logRecord, key string) *anyValue {
+	for i := range rec.Attributes {
+		if rec.Attributes[i].Key == key {
+			return &rec.Attributes[i].Value
+		}
+	}
+	return nil
+}
+
+// TestWriterExport verifies records are converted and POSTed on Close.
+func TestWriterExport(t *testing.T) {
+	s := &stub{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	w := New(srv.URL)
+	w.SetHeader("Authorization", "Bearer x")
+	w.SetResource("service.name", "api")
+	w.Write([]byte(`time=2026-05-09T10:11:12.345 level=WRN trace=api.0af7651916cd43dd8448eb211c80319c.b7ad6b7169203331 caller=/main.go:42 user=alice n=3 ok=true msg="hello world"` + "\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	recs := s.records()
+	if len(recs) != 1 {
+		t.Fatalf("want 1 record, got %d", len(recs))
+	}
+	rec := recs[0]
+	if rec.SeverityNumber != 13 || rec.SeverityText != "WRN" {
+		t.Fatalf("severity mismatch: %+v", rec)
+	}
+	if rec.Body.StringValue == nil || *rec.Body.StringValue != "hello world" {
+		t.Fatalf("body mismatch: %+v", rec.Body)
+	}
+	if rec.TraceID != "0af7651916cd43dd8448eb211c80319c" || rec.SpanID != "b7ad6b7169203331" {
+		t.Fatalf("trace ids mismatch: %s %s", rec.TraceID, rec.SpanID)
+	}
+	want := time.Date(2026, 5, 9, 10, 11, 12, 345e6, time.Local).UnixNano()
+	if rec.TimeUnixNano != strconv.FormatInt(want, 10) {
+		t.Fatalf("time mismatch: %s", rec.TimeUnixNano)
+	}
+	if v := attr(rec, "user"); v == nil || *v.StringValue != "alice" {
+		t.Fatalf("user attribute mismatch")
+	}
+	if v := attr(rec, "n"); v == nil || v.IntValue == nil || *v.IntValue != "3" {
+		t.Fatalf("int attribute mismatch")
+	}
+	if v := attr(rec, "ok"); v == nil || v.BoolValue == nil || !*v.BoolValue {
+		t.Fatalf("bool attribute mismatch")
+	}
+	if v := attr(rec, "namespace"); v == nil || *v.StringValue != "api" {
+		t.Fatalf("namespace attribute mismatch")
+	}
+	if v := attr(rec, "code.lineno"); v == nil || *v.IntValue != "42" {
+		t.Fatalf("code.lineno mismatch")
+	}
+	if s.auth[0] != "Bearer x" {
+		t.Fatalf("header not sent: %q", s.auth[0])
+	}
+	res := s.reqs[0].ResourceLogs[0].Resource.Attributes
+	if len(res) != 1 || *res[0].Value.StringValue != "api" {
+		t.Fatalf("resource mismatch: %+v", res)
+	}
+}
+
+// TestWriterBatch verifies a full batch is exported without waiting for the interval.
+func TestWriterBatch(t *testing.T) {
+	s := &stub{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	w := New(srv.URL)
+	defer w.Close()
+	w.SetBatch(2)
+	w.Write([]byte("level=INF msg=a\n"))
+	w.Write([]byte("level=INF msg=b\n"))
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.records()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := len(s.records()); n != 2 {
+		t.Fatalf("want 2 records exported by batch trigger, got %d", n)
+	}
+}
+
+// TestWriterRequeue verifies failed batches are kept and retried.
+func TestWriterRequeue(t *testing.T) {
+	s := &stub{fail: true}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	w := New(srv.URL)
+	w.Write([]byte("level=ERR msg=a\n"))
+	if err := w.Flush(); err == nil {
+		t.Fatal("expected error from failing endpoint")
+	}
+	s.mu.Lock()
+	s.fail = false
+	s.mu.Unlock()
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if n := len(s.records()); n != 1 {
+		t.Fatalf("requeued record not exported, got %d", n)
+	}
+	if _, err := w.Write([]byte("level=INF msg=late\n")); err == nil {
+		t.Fatal("expected error writing after close")
+	}
+}
+
+// TestTraceIDs verifies only real W3C ids become trace ids, generated ids
+// are split off, and the rest is the namespace.
+func TestTraceIDs(t *testing.T) {
+	cases := []struct{ trace, tid, span, id, ns string }{
+		{"api.k2m3n4p5", "", "", "k2m3n4p5", "api"},
+		{"k2m3n4p5.child", "", "", "k2m3n4p5", "child"},
+		{"api.database", "", "", "", "api.database"},
+		{"api.req-1", "", "", "", "api.req-1"},
+		{"api.0AF7651916CD43DD8448EB211C80319C", "0af7651916cd43dd8448eb211c80319c", "", "", "api"},
+		{"api.0af7651916cd43dd8448eb211c80319c.b7ad6b7169203331.db", "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331", "", "api.db"},
+		{"0af7651916cd43dd8448eb211c80319c", "0af7651916cd43dd8448eb211c80319c", "", "", ""},
+	}
+	for _, c := range cases {
+		tid, span, id, ns := traceIDs(c.trace)
+		if tid != c.tid || span != c.span || id != c.id || ns != c.ns {
+			t.Errorf("traceIDs(%q) = %q %q %q %q, want %q %q %q %q", c.trace, tid, span, id, ns, c.tid, c.span, c.id, c.ns)
+		}
+	}
+}
+
+// TestWriterClock verifies observed times come from the configured clock
+// and a generated trace id is exported as an attribute.
+func TestWriterClock(t *testing.T) {
+	s := &stub{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	at := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	w := New(srv.URL)
+	w.SetClock(func() time.Time { return at })
+	w.Write([]byte("level=INF trace=api.k2m3n4p5 msg=a\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	recs := s.records()
+	want := strconv.FormatInt(at.UnixNano(), 10)
+	if len(recs) != 1 || recs[0].ObservedTimeUnixNano != want || recs[0].TimeUnixNano != want {
+		t.Fatalf("observed time mismatch: %+v", recs)
+	}
+	if recs[0].TraceID != "" || recs[0].SpanID != "" {
+		t.Fatalf("made-up trace ids: %+v", recs[0])
+	}
+	if v := attr(recs[0], "namespace"); v == nil || *v.StringValue != "api" {
+		t.Fatalf("namespace attribute mismatch")
+	}
+	if v := attr(recs[0], "trace_id"); v == nil || *v.StringValue != "k2m3n4p5" {
+		t.Fatalf("trace_id attribute mismatch")
+	}
+}
@@ -15,6 +15,14 @@ func PutKey(dst []byte, key string) []byte {
 	return append(quoteString(dst, key, true), '=')
 }
 
+// PutKeyBytes is a mirror of PutKey with []byte arg.
+func PutKeyBytes(dst, key []byte) []byte {
+	if len(dst) > 0 {
+		dst = append(dst, ' ')
+	}
+	return append(putBytes(dst, key, true), '=')
+}
+
 // PutKeyRaw appends a new key without quoting check.
 // Use for internal keys that are known to be valid (no spaces/tabs).
 func PutKeyRaw(dst []byte, key string) []byte {
@@ -152,13 +160,38 @@ func PutFloat64(dst []byte, val float64) []byte {
 // 含空格/制表符（如 map 键含空格、struct 字段含空格），产出的 key={...}
 // 片段会带裸空格，可能影响按空格切分字段的 logfmt 解析。
 // 取舍：保留 JSON 原貌优先于 logfmt 严格性；调用方应避免对含空格的复杂值
-// 使用 Any，必要时改用带引号的字符串字段。
+// 使用 Any，必要时改用带引号的字符串字段。严格模式（Encoder.Strict）下改为
+// 按 logfmt 字符串加引号转义输出。
+//
+// A panicking MarshalJSON (or String inside it) is rendered as a !PANIC placeholder.
 func PutAny(dst []byte, i any) []byte {
-	marshaled, err := json.Marshal(i)
+	return Encoder{}.PutAny(dst, i)
+}
+
+// PutJSON appends the JSON encoding of i, for values nested inside other
+// JSON: it is never quoted as a logfmt string. Marshaling errors and panics
+// are appended as JSON strings.
+func PutJSON(dst []byte, i any) []byte {
+	data, msg := marshal(i)
+	if msg != "" {
+		return PutJSONString(dst, msg)
+	}
+	return append(dst, data...)
+}
+
+// marshal is json.Marshal reporting a failure, or a panicking MarshalJSON,
+// as msg instead.
+func marshal(i any) (data []byte, msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			data, msg = nil, PanicValue(r)
+		}
+	}()
+	data, err := json.Marshal(i)
 	if err != nil {
-		return PutStringQuote(dst, fmt.Sprintf("marshaling error: %v", err))
+		return nil, fmt.Sprintf("marshaling error: %v", err)
 	}
-	return append(dst, marshaled...)
+	return data, ""
 }
 
 // Thank for github.com/rs/zerolog
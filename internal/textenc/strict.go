@@ -0,0 +1,336 @@
+package textenc
+
+import (
+	"fmt"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Encoder writes logfmt keys and values. The zero Encoder writes the default
+// format: only values with spaces are quoted and raw JSON is written
+// verbatim. With Strict set, any value that contains a space, '=' or a
+// character needing an escape, or that starts with '{' or '[', is written as
+// a quoted string; raw JSON from PutAny, PutRaw and QuoteRaw is quoted the
+// same way, and PutKey replaces invalid key characters with '_'. The output
+// then parses with any logfmt parser.
+type Encoder struct {
+	Strict bool
+}
+
+// PutKey appends a new key, sanitized in strict mode.
+func (e Encoder) PutKey(dst []byte, key string) []byte {
+	if !e.Strict {
+		return PutKey(dst, key)
+	}
+	if len(dst) > 0 {
+		dst = append(dst, ' ')
+	}
+	return append(sanitizeKey(dst, key), '=')
+}
+
+// PutKeyBytes is a mirror of PutKey with []byte arg.
+func (e Encoder) PutKeyBytes(dst, key []byte) []byte {
+	if !e.Strict {
+		return PutKeyBytes(dst, key)
+	}
+	if len(dst) > 0 {
+		dst = append(dst, ' ')
+	}
+	return append(sanitizeKeyBytes(dst, key), '=')
+}
+
+// PutString is PutString, quoting in strict mode as needed.
+func (e Encoder) PutString(dst []byte, s string) []byte {
+	if e.Strict {
+		return putStrict(dst, s)
+	}
+	return PutString(dst, s)
+}
+
+// PutStringQuote is PutStringQuote, quoting in strict mode as needed.
+func (e Encoder) PutStringQuote(dst []byte, s string) []byte {
+	if e.Strict {
+		return putStrict(dst, s)
+	}
+	return PutStringQuote(dst, s)
+}
+
+// PutBytes is PutBytes, quoting in strict mode as needed.
+func (e Encoder) PutBytes(dst, s []byte) []byte {
+	if e.Strict {
+		return putStrictBytes(dst, s)
+	}
+	return PutBytes(dst, s)
+}
+
+// PutBytesQuote is PutBytesQuote, quoting in strict mode as needed.
+func (e Encoder) PutBytesQuote(dst, s []byte) []byte {
+	if e.Strict {
+		return putStrictBytes(dst, s)
+	}
+	return PutBytesQuote(dst, s)
+}
+
+// PutStringer appends the quoted val.String() (nil renders as nil).
+// A panicking String method is rendered as a !PANIC placeholder.
+func (e Encoder) PutStringer(dst []byte, val fmt.Stringer) (out []byte) {
+	if val == nil {
+		return PutNil(dst)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			out = e.PutStringQuote(dst, PanicValue(r))
+		}
+	}()
+	return e.PutStringQuote(dst, val.String())
+}
+
+// PutError appends the quoted err.Error() (nil renders as nil).
+// A panicking Error method is rendered as a !PANIC placeholder.
+func (e Encoder) PutError(dst []byte, err error) (out []byte) {
+	if err == nil {
+		return PutNil(dst)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			out = e.PutStringQuote(dst, PanicValue(r))
+		}
+	}()
+	return e.PutStringQuote(dst, err.Error())
+}
+
+// PutDuration appends d as a duration string ("1.5s").
+func (e Encoder) PutDuration(dst []byte, d time.Duration) []byte {
+	return e.PutString(dst, d.String())
+}
+
+// PutAny marshals i to JSON and appends it as a raw value (see PutRaw).
+// Marshaling errors and panics are appended as quoted strings.
+func (e Encoder) PutAny(dst []byte, i any) []byte {
+	data, msg := marshal(i)
+	if msg != "" {
+		return e.PutStringQuote(dst, msg)
+	}
+	return e.PutRaw(dst, data)
+}
+
+// PutRaw appends a pre-encoded value: verbatim, or in strict mode as a
+// logfmt string when it is not a bare token.
+func (e Encoder) PutRaw(dst, raw []byte) []byte {
+	if e.Strict {
+		return putStrictBytes(dst, raw)
+	}
+	return append(dst, raw...)
+}
+
+// QuoteRaw applies PutRaw to the raw value already written at dst[start:],
+// so that encoders can write JSON straight into dst.
+func (e Encoder) QuoteRaw(dst []byte, start int) []byte {
+	if !e.Strict || !needQuoteStrictBytes(dst[start:]) {
+		return dst
+	}
+	return requote(dst, start, putStrictBytes)
+}
+
+// PutText is PutRaw for raw JSON holding free text, such as stack frames and
+// error messages: outside strict mode it is also quoted, as by
+// PutBytesQuote, when it contains a space or tab.
+func (e Encoder) PutText(dst, raw []byte) []byte {
+	if e.Strict {
+		return putStrictBytes(dst, raw)
+	}
+	if hasSpace(raw) {
+		return PutBytesQuote(dst, raw)
+	}
+	return append(dst, raw...)
+}
+
+// QuoteText applies PutText to the raw value already written at dst[start:].
+func (e Encoder) QuoteText(dst []byte, start int) []byte {
+	if e.Strict {
+		return e.QuoteRaw(dst, start)
+	}
+	if !hasSpace(dst[start:]) {
+		return dst
+	}
+	return requote(dst, start, PutBytesQuote)
+}
+
+// hasSpace reports whether s contains a space or tab.
+func hasSpace(s []byte) bool {
+	for _, c := range s {
+		if c == ' ' || c == '\t' {
+			return true
+		}
+	}
+	return false
+}
+
+// requote re-encodes the value at dst[start:] with put.
+func requote(dst []byte, start int, put func(dst, s []byte) []byte) []byte {
+	end := len(dst)
+	// Encode after the raw value, reading from the original backing array
+	// even if append moves dst, then slide the result back over the raw value.
+	dst = put(dst, dst[start:end])
+	n := copy(dst[start:], dst[end:])
+	return dst[:start+n]
+}
+
+// ValidKey reports whether key can be written as a bare logfmt key: it is
+// non-empty valid UTF-8 without '=', '"', '\\', spaces or control characters.
+func ValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); {
+		c := key[i]
+		if c < utf8.RuneSelf {
+			if !keyTable[c] {
+				return false
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(key[i:])
+		if !validKeyRune(r, size) {
+			return false
+		}
+		i += size
+	}
+	return true
+}
+
+// keyTable marks the ASCII bytes allowed in a bare key.
+var keyTable = [utf8.RuneSelf]bool{}
+
+func init() {
+	for i := 0x21; i < 0x7f; i++ {
+		keyTable[i] = i != '=' && i != '"' && i != '\\'
+	}
+}
+
+// validKeyRune reports whether a decoded non-ASCII rune is allowed in a key.
+func validKeyRune(r rune, size int) bool {
+	return !(r == utf8.RuneError && size == 1) && !unicode.IsControl(r) && !unicode.IsSpace(r)
+}
+
+// sanitizeKey appends key with every invalid character replaced by '_'.
+func sanitizeKey(dst []byte, key string) []byte {
+	if key == "" {
+		return append(dst, '_')
+	}
+	for i := 0; i < len(key); {
+		c := key[i]
+		if c < utf8.RuneSelf {
+			if !keyTable[c] {
+				c = '_'
+			}
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(key[i:])
+		if validKeyRune(r, size) {
+			dst = append(dst, key[i:i+size]...)
+		} else {
+			dst = append(dst, '_')
+		}
+		i += size
+	}
+	return dst
+}
+
+// sanitizeKeyBytes is a mirror of sanitizeKey with []byte arg.
+func sanitizeKeyBytes(dst, key []byte) []byte {
+	if len(key) == 0 {
+		return append(dst, '_')
+	}
+	for i := 0; i < len(key); {
+		c := key[i]
+		if c < utf8.RuneSelf {
+			if !keyTable[c] {
+				c = '_'
+			}
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(key[i:])
+		if validKeyRune(r, size) {
+			dst = append(dst, key[i:i+size]...)
+		} else {
+			dst = append(dst, '_')
+		}
+		i += size
+	}
+	return dst
+}
+
+// needQuoteStrict reports whether s must be quoted in strict mode. Values
+// starting with '{' or '[' are quoted as well, since decoders read such bare
+// values as JSON.
+func needQuoteStrict(s string) bool {
+	if len(s) > 0 && (s[0] == '{' || s[0] == '[') {
+		return true
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if !noEscapeTable[c] || c == ' ' || c == '=' {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// needQuoteStrictBytes is a mirror of needQuoteStrict with []byte arg.
+func needQuoteStrictBytes(s []byte) bool {
+	if len(s) > 0 && (s[0] == '{' || s[0] == '[') {
+		return true
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if !noEscapeTable[c] || c == ' ' || c == '=' {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// putStrict appends s for strict mode: verbatim when clean, else quoted.
+func putStrict(dst []byte, s string) []byte {
+	if !needQuoteStrict(s) {
+		return append(dst, s...)
+	}
+	dst = append(dst, '"')
+	dst = appendStringComplex(dst, s, 0)
+	return append(dst, '"')
+}
+
+// putStrictBytes is a mirror of putStrict with []byte arg.
+func putStrictBytes(dst, s []byte) []byte {
+	if !needQuoteStrictBytes(s) {
+		return append(dst, s...)
+	}
+	dst = append(dst, '"')
+	dst = appendBytesComplex(dst, s, 0)
+	return append(dst, '"')
+}
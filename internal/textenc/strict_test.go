@@ -0,0 +1,123 @@
+package textenc
+
+import "testing"
+
+// TestStrictValues verifies strict mode quotes everything that is not a bare token.
+func TestStrictValues(t *testing.T) {
+	e := Encoder{Strict: true}
+	for _, tt := range []struct{ in, out string }{
+		{"plain", "plain"},
+		{"", ""},
+		{"a b", `"a b"`},
+		{"a=b", `"a=b"`},
+		{`q"`, `"q\""`},
+		{`back\slash`, `"back\\slash"`},
+		{"tab\t", `"tab\t"`},
+		{"\x7f", `"\u007f"`},
+		{"bad\xff", `"bad\ufffd"`},
+		{"世界", "世界"},
+		{"[x", `"[x"`},
+		{"{}", `"{}"`},
+	} {
+		if got := string(e.PutString(nil, tt.in)); got != tt.out {
+			t.Errorf("PutString(%q) = %s, want %s", tt.in, got, tt.out)
+		}
+		if got := string(e.PutStringQuote(nil, tt.in)); got != tt.out {
+			t.Errorf("PutStringQuote(%q) = %s, want %s", tt.in, got, tt.out)
+		}
+		if got := string(e.PutBytesQuote(nil, []byte(tt.in))); got != tt.out {
+			t.Errorf("PutBytesQuote(%q) = %s, want %s", tt.in, got, tt.out)
+		}
+	}
+	if got := string(e.PutAny(nil, map[string]int{"a b": 1})); got != `"{\"a b\":1}"` {
+		t.Errorf("PutAny = %s", got)
+	}
+	if got := string(e.PutAny(nil, 12)); got != `12` {
+		t.Errorf("PutAny bare = %s", got)
+	}
+	if got := string(e.PutRaw(nil, []byte(`{"k": 1}`))); got != `"{\"k\": 1}"` {
+		t.Errorf("PutRaw = %s", got)
+	}
+	dst := append([]byte("k="), `["a b"]`...)
+	if got := string(e.QuoteRaw(dst, 2)); got != `k="[\"a b\"]"` {
+		t.Errorf("QuoteRaw = %s", got)
+	}
+}
+
+// TestStrictOff verifies the default mode keeps raw values verbatim.
+func TestStrictOff(t *testing.T) {
+	var e Encoder
+	if got := string(e.PutRaw(nil, []byte(`{"k": 1}`))); got != `{"k": 1}` {
+		t.Errorf("PutRaw = %s", got)
+	}
+	dst := []byte(`k=["a b"]`)
+	if got := string(e.QuoteRaw(dst, 2)); got != `k=["a b"]` {
+		t.Errorf("QuoteRaw = %s", got)
+	}
+	if got := string(e.PutKey(nil, "a b")); got != `"a b"=` {
+		t.Errorf("PutKey = %s", got)
+	}
+}
+
+// TestStrictKeys verifies key validation and sanitizing.
+func TestStrictKeys(t *testing.T) {
+	e := Encoder{Strict: true}
+	for _, tt := range []struct {
+		in, out string
+		valid   bool
+	}{
+		{"key", "key", true},
+		{"a.b-c", "a.b-c", true},
+		{"用户", "用户", true},
+		{"", "_", false},
+		{"a b", "a_b", false},
+		{"a=b", "a_b", false},
+		{`"q"`, "_q_", false},
+		{`a\b`, "a_b", false},
+		{"nl\n", "nl_", false},
+		{"bad\xff", "bad_", false},
+		{"nbsp ", "nbsp_", false},
+		{"c1\u0085", "c1_", false},
+	} {
+		if got := ValidKey(tt.in); got != tt.valid {
+			t.Errorf("ValidKey(%q) = %v", tt.in, got)
+		}
+		if got := string(e.PutKey(nil, tt.in)); got != tt.out+"=" {
+			t.Errorf("PutKey(%q) = %s, want %s=", tt.in, got, tt.out)
+		}
+		if got := string(e.PutKeyBytes([]byte("x"), []byte(tt.in))); got != "x "+tt.out+"=" {
+			t.Errorf("PutKeyBytes(%q) = %s", tt.in, got)
+		}
+	}
+}
+
+// TestPutJSON verifies nested JSON is never quoted, whatever the mode.
+func TestPutJSON(t *testing.T) {
+	if got := string(PutJSON(nil, map[string]int{"a b": 1})); got != `{"a b":1}` {
+		t.Errorf("PutJSON = %s", got)
+	}
+	if got := string(PutJSON(nil, func() {})); got != `"marshaling error: json: unsupported type: func()"` {
+		t.Errorf("PutJSON error = %s", got)
+	}
+}
+
+// TestQuoteText verifies free-text JSON is quoted when it has spaces, in both modes.
+func TestQuoteText(t *testing.T) {
+	for _, tt := range []struct {
+		strict  bool
+		in, out string
+	}{
+		{false, `["a.b /x.go:1"]`, `"[\"a.b /x.go:1\"]"`},
+		{false, `["a.b@/x.go:1"]`, `["a.b@/x.go:1"]`},
+		{true, `["a.b /x.go:1"]`, `"[\"a.b /x.go:1\"]"`},
+		{true, `["a.b@/x.go:1"]`, `"[\"a.b@/x.go:1\"]"`},
+	} {
+		dst := append([]byte("k="), tt.in...)
+		if got := string(Encoder{Strict: tt.strict}.QuoteText(dst, 2)); got != "k="+tt.out {
+			t.Errorf("QuoteText(%v, %s) = %s", tt.strict, tt.in, got)
+		}
+		if got := string(Encoder{Strict: tt.strict}.PutText(nil, []byte(tt.in))); got != tt.out {
+			t.Errorf("PutText(%v, %s) = %s", tt.strict, tt.in, got)
+		}
+	}
+}
@@ -71,13 +71,36 @@ func quoteString(dst []byte, s string, quote bool) []byte {
 	return dst
 }
 
+// PutJSONString appends s as an always-quoted JSON string.
+func PutJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	dst = PutEscaped(dst, s)
+	return append(dst, '"')
+}
+
+// PutEscaped appends s JSON-escaped, without quotes, for values assembled
+// from several parts inside one quoted string.
+func PutEscaped(dst []byte, s string) []byte {
+	return appendStringComplex(dst, s, 0)
+}
+
 // PutStringer encodes the input Stringer to json and appends the
 // encoded Stringer value to the input byte slice.
+// A panicking String method is rendered as a !PANIC placeholder.
 func PutStringer(dst []byte, val fmt.Stringer) []byte {
-	if val == nil {
-		return PutNil(dst)
-	}
-	return PutStringQuote(dst, val.String())
+	return Encoder{}.PutStringer(dst, val)
+}
+
+// PutError appends the quoted err.Error() (nil renders as nil).
+// A panicking Error method is rendered as a !PANIC placeholder.
+func PutError(dst []byte, err error) []byte {
+	return Encoder{}.PutError(dst, err)
+}
+
+// PanicValue renders a value recovered from a String/Error/MarshalJSON call,
+// as !PANIC(type: value), e.g. !PANIC(string: boom).
+func PanicValue(r any) string {
+	return fmt.Sprintf("!PANIC(%T: %v)", r, r)
 }
 
 // appendStringComplex takes over from quoteString when a character that
@@ -78,3 +78,57 @@ func BenchmarkPutString(b *testing.B) {
 		})
 	}
 }
+
+type nilStringer struct{ s *string }
+
+func (n *nilStringer) String() string { return *n.s }
+
+type badError struct{}
+
+func (badError) Error() string { panic("boom") }
+
+type badJSON struct{}
+
+func (badJSON) MarshalJSON() ([]byte, error) { panic(badError{}) }
+
+// TestPutPanics verifies panicking String/Error/MarshalJSON calls render a placeholder.
+func TestPutPanics(t *testing.T) {
+	var np *nilStringer
+	cases := []struct {
+		got, want string
+	}{
+		{string(PutStringer([]byte("k="), np)), `k="!PANIC(runtime.errorString: runtime error: invalid memory address or nil pointer dereference)"`},
+		{string(PutError([]byte("error="), badError{})), `error="!PANIC(string: boom)"`},
+		{string(PutError([]byte("error="), nil)), `error=nil`},
+		{string(PutAny([]byte("v="), badJSON{})), `v="!PANIC(textenc.badError: %!v(PANIC=Error method: boom))"`},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("got %s, want %s", c.got, c.want)
+		}
+	}
+}
+
+// TestPutJSONString verifies strings are always quoted and JSON-escaped.
+func TestPutJSONString(t *testing.T) {
+	for _, tt := range []struct{ in, out string }{
+		{"", `""`},
+		{"a b", `"a b"`},
+		{`q"\`, `"q\"\\"`},
+		{"\n\x01", `"\n\u0001"`},
+	} {
+		if got := string(PutJSONString(nil, tt.in)); got != tt.out {
+			t.Errorf("PutJSONString(%q) = %s, want %s", tt.in, got, tt.out)
+		}
+	}
+}
+
+// TestPutEscaped verifies PutEscaped escapes like PutJSONString without the quotes.
+func TestPutEscaped(t *testing.T) {
+	for _, s := range []string{"", "a b", `q"\`, "\n\x01", "é\xff"} {
+		q := string(PutJSONString(nil, s))
+		if got := string(PutEscaped(nil, s)); got != q[1:len(q)-1] {
+			t.Errorf("PutEscaped(%q) = %s, want %s", s, got, q[1:len(q)-1])
+		}
+	}
+}
@@ -3,6 +3,7 @@ package logs
 import (
 	"context"
 	"io"
+	"os"
 	"strings"
 
 	"github.com/zxysilent/logs/internal/file"
@@ -78,9 +79,10 @@ func ParseLevel(s string) Level {
 // Logger is a lightweight handle that shares the root Config.
 // Loggers constructed via New are immutable after creation.
 type Logger struct {
-	cfg   *config // shared root config
-	trace string  // namespace / trace
-	attr  []byte  // frozen preset fields (nil for plain loggers)
+	cfg   *config  // shared root config
+	smp   *sampler // record sampler (nil when sampling is off)
+	trace string   // namespace / trace
+	attr  []byte   // frozen preset fields (nil for plain loggers)
 }
 
 // New creates an immutable Logger with the given output and options.
@@ -92,23 +94,41 @@ func New(out io.Writer, opts ...Option) *Logger {
 		out = io.Discard
 	}
 	cfg := &config{
-		out:    out,
-		sep:    []string{"/"},
-		level:  LevelInfo,
-		skip:   0,
-		caller: false,
-		hijack: true,
+		out:      out,
+		sep:      []string{"/"},
+		level:    LevelInfo,
+		skip:     0,
+		caller:   false,
+		hijack:   true,
+		fallback: os.Stderr,
+
+		stackLevel: LevelMute,
+		stackDepth: defaultStackDepth,
 	}
 	for _, opt := range opts {
 		opt(cfg)
 	}
-	l := &Logger{cfg: cfg}
+	l := &Logger{cfg: cfg, smp: cfg.smp}
 	if l.cfg.hijack {
 		l.hijackstd()
 	}
 	return l
 }
 
+// WriteErrors returns the number of records the output failed to write.
+func (l *Logger) WriteErrors() uint64 {
+	return l.cfg.failed.Load()
+}
+
+// Flush writes the pending WithDedup repeat summary, if any. Call it before
+// the program exits or the output is closed; otherwise the summary is only
+// written when the next record or the window timer arrives.
+func (l *Logger) Flush() {
+	if l.cfg.dup != nil {
+		l.cfg.dup.flush(l.cfg)
+	}
+}
+
 // NewFile opens a log file writer, returning the Writer and its close handle.
 func NewFile(path string, opts ...FileOption) (io.Writer, func() error) {
 	fw := file.New(path, true)
@@ -130,7 +150,7 @@ func (l *Logger) preb() *buffer {
 // Trace 派生一个子 Logger，使用 trace 替换当前命名空间（不拼接），保留预设字段。
 // Trace derives a child Logger, replacing the current namespace with trace (no joining), preserving preset fields.
 func (l *Logger) Trace(trace string) *Logger {
-	c := &Logger{cfg: l.cfg, trace: trace}
+	c := &Logger{cfg: l.cfg, smp: l.smp, trace: trace}
 	if len(l.attr) > 0 {
 		c.attr = make([]byte, len(l.attr))
 		copy(c.attr, l.attr)
@@ -145,7 +165,7 @@ func (l *Logger) Clone(trace ...string) *Logger {
 	if len(trace) > 0 {
 		nt = joinTrace(l.trace, trace[0])
 	}
-	c := &Logger{cfg: l.cfg, trace: nt}
+	c := &Logger{cfg: l.cfg, smp: l.smp, trace: nt}
 	if len(l.attr) > 0 {
 		c.attr = make([]byte, len(l.attr))
 		copy(c.attr, l.attr)
@@ -158,6 +178,7 @@ func (l *Logger) Clone(trace ...string) *Logger {
 func (l *Logger) With(trace ...string) *fielder {
 	f := getfl()
 	f.cfg = l.cfg
+	f.smp = l.smp
 	f.caller = l.cfg.caller
 	f.attr = getb()
 	*f.attr = append(*f.attr, l.attr...)
@@ -174,14 +195,44 @@ func (l *Logger) With(trace ...string) *fielder {
 func (l *Logger) Ctx(ctx context.Context) *fielder {
 	f := getfl()
 	f.cfg = l.cfg
+	f.smp = l.smp
 	f.caller = l.cfg.caller
 	f.attr = getb()
 	*f.attr = append(*f.attr, l.attr...)
+	f.ctx = ctx
 	tid, _ := ctx.Value(traceKey).(string)
 	f.trace = joinTrace(l.trace, tid)
 	return f
 }
 
+// At 以等级开头创建一次性 fielder，以 Msg/Msgf 结束；等级未开启时返回空操作 fielder，字段方法不做任何编码。
+// At starts a level-first chain finished by Msg/Msgf. When lv is disabled the
+// returned fielder is a no-op: field methods encode nothing and Msg writes nothing.
+func (l *Logger) At(lv Level) *fielder {
+	if lv < l.cfg.level || lv >= LevelMute {
+		f := getfl()
+		f.cfg = l.cfg
+		f.lv = lv
+		f.skip = true
+		return f
+	}
+	f := l.With()
+	f.lv = lv
+	return f
+}
+
+// DebugWith starts a debug-level chain; see At.
+func (l *Logger) DebugWith() *fielder { return l.At(LevelDebug) }
+
+// InfoWith starts an info-level chain; see At.
+func (l *Logger) InfoWith() *fielder { return l.At(LevelInfo) }
+
+// WarnWith starts a warn-level chain; see At.
+func (l *Logger) WarnWith() *fielder { return l.At(LevelWarn) }
+
+// ErrorWith starts an error-level chain; see At.
+func (l *Logger) ErrorWith() *fielder { return l.At(LevelError) }
+
 // joinTrace joins namespace and sub-trace.
 func joinTrace(base, sub string) string {
 	if base != "" && sub != "" {
@@ -195,56 +246,84 @@ func joinTrace(base, sub string) string {
 
 // Debug logs at debug level.
 func (l *Logger) Debug(args ...any) {
-	if LevelDebug >= l.cfg.level {
-		l.cfg.print(l.trace, LevelDebug, l.cfg.caller, l.preb(), args...)
+	if LevelDebug >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelDebug, 0, msgOf(args))) {
+		l.cfg.print(nil, l.trace, LevelDebug, l.cfg.caller, 0, l.preb(), args...)
 	}
 }
 
 // Debugf logs a formatted message at debug level.
 func (l *Logger) Debugf(format string, args ...any) {
-	if LevelDebug >= l.cfg.level {
-		l.cfg.printf(l.trace, LevelDebug, l.cfg.caller, l.preb(), format, args...)
+	if LevelDebug >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelDebug, 0, format)) {
+		l.cfg.printf(nil, l.trace, LevelDebug, l.cfg.caller, 0, l.preb(), format, args...)
 	}
 }
 
 // Info logs at info level.
 func (l *Logger) Info(args ...any) {
-	if LevelInfo >= l.cfg.level {
-		l.cfg.print(l.trace, LevelInfo, l.cfg.caller, l.preb(), args...)
+	if LevelInfo >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelInfo, 0, msgOf(args))) {
+		l.cfg.print(nil, l.trace, LevelInfo, l.cfg.caller, 0, l.preb(), args...)
 	}
 }
 
 // Infof logs a formatted message at info level.
 func (l *Logger) Infof(format string, args ...any) {
-	if LevelInfo >= l.cfg.level {
-		l.cfg.printf(l.trace, LevelInfo, l.cfg.caller, l.preb(), format, args...)
+	if LevelInfo >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelInfo, 0, format)) {
+		l.cfg.printf(nil, l.trace, LevelInfo, l.cfg.caller, 0, l.preb(), format, args...)
 	}
 }
 
 // Warn logs at warn level.
 func (l *Logger) Warn(args ...any) {
-	if LevelWarn >= l.cfg.level {
-		l.cfg.print(l.trace, LevelWarn, l.cfg.caller, l.preb(), args...)
+	if LevelWarn >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelWarn, 0, msgOf(args))) {
+		l.cfg.print(nil, l.trace, LevelWarn, l.cfg.caller, 0, l.preb(), args...)
 	}
 }
 
 // Warnf logs a formatted message at warn level.
 func (l *Logger) Warnf(format string, args ...any) {
-	if LevelWarn >= l.cfg.level {
-		l.cfg.printf(l.trace, LevelWarn, l.cfg.caller, l.preb(), format, args...)
+	if LevelWarn >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelWarn, 0, format)) {
+		l.cfg.printf(nil, l.trace, LevelWarn, l.cfg.caller, 0, l.preb(), format, args...)
 	}
 }
 
 // Error logs at error level.
 func (l *Logger) Error(args ...any) {
-	if LevelError >= l.cfg.level {
-		l.cfg.print(l.trace, LevelError, l.cfg.caller, l.preb(), args...)
+	if LevelError >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelError, 0, msgOf(args))) {
+		l.cfg.print(nil, l.trace, LevelError, l.cfg.caller, 0, l.preb(), args...)
 	}
 }
 
 // Errorf logs a formatted message at error level.
 func (l *Logger) Errorf(format string, args ...any) {
-	if LevelError >= l.cfg.level {
-		l.cfg.printf(l.trace, LevelError, l.cfg.caller, l.preb(), format, args...)
+	if LevelError >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelError, 0, format)) {
+		l.cfg.printf(nil, l.trace, LevelError, l.cfg.caller, 0, l.preb(), format, args...)
+	}
+}
+
+// Debugw logs msg at debug level with alternating key/value fields.
+func (l *Logger) Debugw(msg string, kv ...any) {
+	if LevelDebug >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelDebug, 0, msg)) {
+		l.cfg.printw(nil, l.trace, LevelDebug, l.cfg.caller, 0, l.preb(), nil, msg, kv...)
+	}
+}
+
+// Infow logs msg at info level with alternating key/value fields.
+func (l *Logger) Infow(msg string, kv ...any) {
+	if LevelInfo >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelInfo, 0, msg)) {
+		l.cfg.printw(nil, l.trace, LevelInfo, l.cfg.caller, 0, l.preb(), nil, msg, kv...)
+	}
+}
+
+// Warnw logs msg at warn level with alternating key/value fields.
+func (l *Logger) Warnw(msg string, kv ...any) {
+	if LevelWarn >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelWarn, 0, msg)) {
+		l.cfg.printw(nil, l.trace, LevelWarn, l.cfg.caller, 0, l.preb(), nil, msg, kv...)
+	}
+}
+
+// Errorw logs msg at error level with alternating key/value fields.
+func (l *Logger) Errorw(msg string, kv ...any) {
+	if LevelError >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelError, 0, msg)) {
+		l.cfg.printw(nil, l.trace, LevelError, l.cfg.caller, 0, l.preb(), nil, msg, kv...)
 	}
 }
@@ -71,6 +71,31 @@ func SetConsole(b bool) {
 	l.cfg.setConsole(b)
 }
 
+// SetErrorHandler sets a handler called for every failed write.
+func SetErrorHandler(h ErrorHandler) {
+	l.cfg.setErrorHandler(h)
+}
+
+// SetFallback sets the writer that receives records the output failed to write (nil drops them).
+func SetFallback(w io.Writer) {
+	l.cfg.setFallback(w)
+}
+
+// WriteErrors returns the number of records the default instance failed to write.
+func WriteErrors() uint64 {
+	return l.WriteErrors()
+}
+
+// SetMetrics counts the default instance's records into m (nil disables).
+func SetMetrics(m *Metrics) {
+	l.cfg.metrics = m
+}
+
+// SetStrictLogfmt sets whether the default instance writes strict logfmt (see WithStrictLogfmt).
+func SetStrictLogfmt(b bool) {
+	l.cfg.enc.Strict = b
+}
+
 // SetTrace sets the trace.
 func SetTrace(trace string) {
 	l.trace = trace
@@ -105,6 +130,21 @@ var Error = l.Error
 // Errorf logs a formatted message at error level.
 var Errorf = l.Errorf
 
+// Debugw logs msg at debug level with alternating key/value fields.
+var Debugw = l.Debugw
+
+// Infow logs msg at info level with alternating key/value fields.
+var Infow = l.Infow
+
+// Warnw logs msg at warn level with alternating key/value fields.
+var Warnw = l.Warnw
+
+// Errorw logs msg at error level with alternating key/value fields.
+var Errorw = l.Errorw
+
+// Log logs msg at lv with typed fields.
+var Log = l.Log
+
 // Print logs at info level (stdlib-compatible).
 var Print = l.Print
 
@@ -119,11 +159,41 @@ func With(trace ...string) *fielder {
 	return l.With(trace...)
 }
 
+// WithFields derives a Logger from the default instance with preset typed fields.
+func WithFields(fields ...Field) *Logger {
+	return l.WithFields(fields...)
+}
+
 // Ctx is the context logging entry.
 func Ctx(ctx context.Context) *fielder {
 	return l.Ctx(ctx)
 }
 
+// At starts a level-first chain on the default instance; see Logger.At.
+func At(lv Level) *fielder {
+	return l.At(lv)
+}
+
+// DebugWith starts a debug-level chain on the default instance.
+func DebugWith() *fielder {
+	return l.At(LevelDebug)
+}
+
+// InfoWith starts an info-level chain on the default instance.
+func InfoWith() *fielder {
+	return l.At(LevelInfo)
+}
+
+// WarnWith starts a warn-level chain on the default instance.
+func WarnWith() *fielder {
+	return l.At(LevelWarn)
+}
+
+// ErrorWith starts an error-level chain on the default instance.
+func ErrorWith() *fielder {
+	return l.At(LevelError)
+}
+
 // Trace replaces the namespace of the default instance and returns a child Logger.
 func Trace(trace string) *Logger {
 	return l.Trace(trace)
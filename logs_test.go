@@ -1612,3 +1612,44 @@ func BenchmarkLastSep(b *testing.B) {
 		benchSink = lastSep(benchPath, benchSeps)
 	}
 }
+
+type panicStringer struct{ name *string }
+
+func (p *panicStringer) String() string { return *p.name }
+
+type panicError struct{}
+
+func (panicError) Error() string { panic("boom") }
+
+type panicJSON struct{}
+
+func (panicJSON) MarshalJSON() ([]byte, error) { panic("boom") }
+
+// TestPanicSafeValues verifies panicking log arguments render a placeholder instead of crashing.
+func TestPanicSafeValues(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	var np *panicStringer
+	l.Info(np)
+	l.Error(panicError{})
+	l.With().Stringer("s", np).Err(panicError{}).Any("v", panicJSON{}).Info("fields")
+	l.Info(1, panicError{})
+	l.Infof("got %v", panicError{})
+	l.Infof("%q|%5s|%d", errors.New("x"), panicError{}, 7)
+	l.Infof("%T %v %T", errors.New("x"), errors.New("y"), time.Second)
+	out := buf.String()
+	for _, want := range []string{
+		`msg="!PANIC(runtime.errorString: runtime error: invalid memory address or nil pointer dereference)"`,
+		`level=ERR msg="!PANIC(string: boom)"`,
+		`s="!PANIC(runtime.errorString:`,
+		`error="!PANIC(string: boom)" v="!PANIC(string: boom)" msg=fields`,
+		`msg="1 !PANIC(string: boom)"`,
+		`msg="got !PANIC(string: boom)"`,
+		`msg="\"x\"|!PANIC(string: boom)|7"`,
+		`msg="*errors.errorString y time.Duration"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %s in:\n%s", want, out)
+		}
+	}
+}
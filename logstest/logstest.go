@@ -0,0 +1,258 @@
+// Package logstest captures records written by a logs.Logger as decoded
+// structs and provides assertion helpers for tests.
+//
+//	l, rec := logstest.New(t)
+//	svc := NewService(l)
+//	svc.Run()
+//	rec.AssertLogged(t, logs.LevelInfo, "started", "port", "8080")
+//	rec.RequireNoErrors(t)
+package logstest
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zxysilent/logs"
+	"github.com/zxysilent/logs/internal/logfmt"
+)
+
+// Field is a decoded custom field.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Record is a decoded log record.
+type Record struct {
+	Time    time.Time
+	Level   logs.Level
+	Trace   string
+	Caller  string
+	Message string
+	Fields  []Field // custom fields in output order
+	Line    string  // raw logfmt line without the trailing newline
+}
+
+// Field returns the value of the first field named key.
+func (r Record) Field(key string) (string, bool) {
+	for _, f := range r.Fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Recorder is an io.Writer that decodes and stores every record.
+// It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	records []Record
+	tb      testing.TB // mirrors records to tb.Log while the test runs
+}
+
+// New returns a Logger writing to a new Recorder. Records are also logged
+// through tb (shown with go test -v or on failure) until the test ends,
+// attributed to the line that logged them.
+// tb may be nil. Options are applied after WithHijack(false), so the
+// standard library logger is left alone unless the caller opts in.
+func New(tb testing.TB, opts ...logs.Option) (*logs.Logger, *Recorder) {
+	rec := NewRecorder(tb)
+	opts = append([]logs.Option{logs.WithHijack(false), logs.WithLevel(logs.LevelDebug)}, opts...)
+	return logs.New(rec, opts...), rec
+}
+
+// NewRecorder returns a Recorder; see New for the meaning of tb.
+func NewRecorder(tb testing.TB) *Recorder {
+	r := &Recorder{tb: tb}
+	if tb != nil {
+		// tb.Log panics once the test has completed; stop mirroring first.
+		tb.Cleanup(func() {
+			r.mu.Lock()
+			r.tb = nil
+			r.mu.Unlock()
+		})
+	}
+	return r
+}
+
+// Write decodes one record and stores it.
+func (r *Recorder) Write(p []byte) (int, error) {
+	d := logfmt.Decode(p)
+	rec := Record{
+		Time:    d.Time,
+		Level:   logs.ParseLevel(d.Level),
+		Trace:   d.Trace,
+		Caller:  d.Caller,
+		Message: d.Msg,
+		Line:    strings.TrimRight(string(p), "\n"),
+	}
+	if len(d.Fields) > 0 {
+		rec.Fields = make([]Field, len(d.Fields))
+		for i, f := range d.Fields {
+			rec.Fields[i] = Field{Key: f.Key, Value: f.Val}
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, rec)
+	if r.tb != nil {
+		mirror(r.tb, rec.Line)
+	}
+	return len(p), nil
+}
+
+// logsPkg is the import path of the logs package.
+const logsPkg = "github.com/zxysilent/logs"
+
+// callerOf returns "file:line" of the code that logged the record being
+// written: the first frame above Recorder.Write outside the logs packages
+// and the standard library logger. It returns "" when there is none, e.g.
+// for a record written from a background goroutine.
+func callerOf() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		pkg := funcPackage(f.Function)
+		switch {
+		case pkg == logsPkg || strings.HasPrefix(pkg, logsPkg+"/internal/") || pkg == "log":
+		case strings.HasPrefix(f.Function, logsPkg+"/logstest.(*Recorder)"):
+		case pkg == "runtime" || pkg == "time":
+			return ""
+		default:
+			return filepath.Base(f.File) + ":" + strconv.Itoa(f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// funcPackage returns the import path of the package a function belongs to.
+func funcPackage(name string) string {
+	slash := strings.LastIndexByte(name, '/')
+	if dot := strings.IndexByte(name[slash+1:], '.'); dot >= 0 {
+		return name[:slash+1+dot]
+	}
+	return name
+}
+
+// Records returns a copy of the recorded records.
+func (r *Recorder) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Record(nil), r.records...)
+}
+
+// Level returns the records logged at exactly lv.
+func (r *Recorder) Level(lv logs.Level) []Record {
+	var out []Record
+	for _, rec := range r.Records() {
+		if rec.Level == lv {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// Reset discards all recorded records.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = nil
+}
+
+// Logged reports whether a record with level lv, message msg and all the
+// given key/value field pairs was recorded.
+func (r *Recorder) Logged(lv logs.Level, msg string, fields ...string) bool {
+	for _, rec := range r.Records() {
+		if matches(rec, lv, msg, fields) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(rec Record, lv logs.Level, msg string, fields []string) bool {
+	if rec.Level != lv || rec.Message != msg {
+		return false
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		if v, ok := rec.Field(fields[i]); !ok || v != fields[i+1] {
+			return false
+		}
+	}
+	return true
+}
+
+// AssertLogged reports a test error unless a matching record was recorded
+// (see Logged). fields are alternating keys and values.
+func (r *Recorder) AssertLogged(t testing.TB, lv logs.Level, msg string, fields ...string) bool {
+	t.Helper()
+	if len(fields)%2 != 0 {
+		t.Fatalf("logstest: AssertLogged fields must be key/value pairs, got %d values", len(fields))
+	}
+	if r.Logged(lv, msg, fields...) {
+		return true
+	}
+	t.Errorf("logstest: no record level=%s msg=%q %s\n%s", lv, msg, pairs(fields), r.dump())
+	return false
+}
+
+// AssertNotLogged reports a test error if a record matching lv and msg was recorded.
+func (r *Recorder) AssertNotLogged(t testing.TB, lv logs.Level, msg string) bool {
+	t.Helper()
+	if !r.Logged(lv, msg) {
+		return true
+	}
+	t.Errorf("logstest: unexpected record level=%s msg=%q\n%s", lv, msg, r.dump())
+	return false
+}
+
+// RequireNoErrors stops the test if any error-level record was recorded.
+func (r *Recorder) RequireNoErrors(t testing.TB) {
+	t.Helper()
+	errs := r.Level(logs.LevelError)
+	if len(errs) == 0 {
+		return
+	}
+	var sb strings.Builder
+	for _, rec := range errs {
+		sb.WriteString("\t")
+		sb.WriteString(rec.Line)
+		sb.WriteString("\n")
+	}
+	t.Fatalf("logstest: %d error record(s) logged:\n%s", len(errs), sb.String())
+}
+
+func pairs(fields []string) string {
+	var sb strings.Builder
+	for i := 0; i+1 < len(fields); i += 2 {
+		fmt.Fprintf(&sb, "%s=%q ", fields[i], fields[i+1])
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// dump formats the recorded lines for failure messages.
+func (r *Recorder) dump() string {
+	recs := r.Records()
+	if len(recs) == 0 {
+		return "\t(no records)"
+	}
+	var sb strings.Builder
+	sb.WriteString("recorded:\n")
+	for _, rec := range recs {
+		sb.WriteString("\t")
+		sb.WriteString(rec.Line)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
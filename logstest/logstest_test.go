@@ -0,0 +1,117 @@
+package logstest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zxysilent/logs"
+)
+
+// fakeTB records failures instead of failing the enclosing test.
+type fakeTB struct {
+	testing.TB
+	errors []string
+	fatals []string
+	logs   []string
+	out    strings.Builder
+}
+
+func (f *fakeTB) Helper()                           {}
+func (f *fakeTB) Log(args ...any)                   { f.logs = append(f.logs, args[0].(string)) }
+func (f *fakeTB) Errorf(format string, args ...any) { f.errors = append(f.errors, format) }
+func (f *fakeTB) Fatalf(format string, args ...any) { f.fatals = append(f.fatals, format) }
+func (f *fakeTB) Cleanup(func())                    {}
+func (f *fakeTB) Output() io.Writer                 { return &f.out }
+
+// output returns everything mirrored through Log and Output.
+func (f *fakeTB) output() string {
+	return strings.Join(f.logs, "\n") + f.out.String()
+}
+
+// TestRecorderDecodes verifies records are stored as decoded structs with ordered fields.
+func TestRecorderDecodes(t *testing.T) {
+	l, rec := New(t, logs.WithCaller(true))
+	ctx := logs.TraceCtx(context.Background(), "req1")
+	l.Trace("api").Ctx(ctx).Str("user", "alice").Int("n", 3).Warn("slow request")
+	l.Debug("dbg")
+
+	recs := rec.Records()
+	if len(recs) != 2 {
+		t.Fatalf("want 2 records, got %d", len(recs))
+	}
+	r := recs[0]
+	if r.Level != logs.LevelWarn || r.Trace != "api.req1" || r.Message != "slow request" {
+		t.Fatalf("record mismatch: %+v", r)
+	}
+	if !strings.HasPrefix(r.Caller, "/logstest_test.go:") || r.Time.IsZero() {
+		t.Fatalf("caller/time mismatch: %+v", r)
+	}
+	want := []Field{{"user", "alice"}, {"n", "3"}}
+	if len(r.Fields) != 2 || r.Fields[0] != want[0] || r.Fields[1] != want[1] {
+		t.Fatalf("fields mismatch: %+v", r.Fields)
+	}
+	if v, ok := r.Field("n"); !ok || v != "3" {
+		t.Fatalf("Field lookup mismatch")
+	}
+	if len(rec.Level(logs.LevelDebug)) != 1 {
+		t.Fatalf("Level filter mismatch")
+	}
+	rec.Reset()
+	if len(rec.Records()) != 0 {
+		t.Fatalf("Reset should discard records")
+	}
+}
+
+// TestAssertLogged verifies matching by level, message and field pairs.
+func TestAssertLogged(t *testing.T) {
+	l, rec := New(nil)
+	l.With().Str("port", "8080").Info("started")
+
+	rec.AssertLogged(t, logs.LevelInfo, "started")
+	rec.AssertLogged(t, logs.LevelInfo, "started", "port", "8080")
+
+	ft := &fakeTB{}
+	if rec.AssertLogged(ft, logs.LevelInfo, "started", "port", "9090") {
+		t.Fatal("field mismatch should not match")
+	}
+	if rec.AssertLogged(ft, logs.LevelWarn, "started") {
+		t.Fatal("level mismatch should not match")
+	}
+	if len(ft.errors) != 2 {
+		t.Fatalf("want 2 reported errors, got %d", len(ft.errors))
+	}
+	rec.AssertLogged(ft, logs.LevelInfo, "started", "port")
+	if len(ft.fatals) != 1 {
+		t.Fatalf("odd field count should be fatal")
+	}
+	if !rec.AssertNotLogged(t, logs.LevelError, "started") || rec.AssertNotLogged(ft, logs.LevelInfo, "started") {
+		t.Fatal("AssertNotLogged mismatch")
+	}
+}
+
+// TestRequireNoErrors verifies error records stop the test.
+func TestRequireNoErrors(t *testing.T) {
+	l, rec := New(nil)
+	l.Warn("fine")
+	rec.RequireNoErrors(t)
+
+	l.With().Err(errors.New("boom")).Error("failed")
+	ft := &fakeTB{}
+	rec.RequireNoErrors(ft)
+	if len(ft.fatals) != 1 {
+		t.Fatalf("RequireNoErrors should fail on error records")
+	}
+}
+
+// TestTBOutput verifies records are mirrored to the test log.
+func TestTBOutput(t *testing.T) {
+	ft := &fakeTB{}
+	l, _ := New(ft)
+	l.Info("inline")
+	if out := strings.TrimSuffix(ft.output(), "\n"); strings.Count(out, "\n") != 0 || !strings.HasSuffix(out, "msg=inline") {
+		t.Fatalf("tb output mismatch: %q", out)
+	}
+}
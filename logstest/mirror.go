@@ -0,0 +1,19 @@
+//go:build go1.25
+
+package logstest
+
+import (
+	"io"
+	"testing"
+)
+
+// mirror logs line through tb, attributed to the code that logged it rather
+// than to Recorder.Write: tb.Helper only marks its direct caller, so the
+// frames inside logs cannot be skipped by testing itself.
+func mirror(tb testing.TB, line string) {
+	if caller := callerOf(); caller != "" {
+		io.WriteString(tb.Output(), caller+": "+line+"\n")
+		return
+	}
+	tb.Log(line)
+}
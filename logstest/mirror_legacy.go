@@ -0,0 +1,16 @@
+//go:build !go1.25
+
+package logstest
+
+import "testing"
+
+// mirror logs line through tb. Without testing.TB.Output (Go 1.25) the line
+// cannot be attributed to the code that logged it, so it is prefixed with
+// that location instead.
+func mirror(tb testing.TB, line string) {
+	tb.Helper()
+	if caller := callerOf(); caller != "" {
+		line = caller + ": " + line
+	}
+	tb.Log(line)
+}
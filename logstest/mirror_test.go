@@ -0,0 +1,35 @@
+//go:build go1.25
+
+package logstest
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestMirrorCaller verifies mirrored lines are attributed to the code that logged them.
+func TestMirrorCaller(t *testing.T) {
+	ft := &fakeTB{}
+	l, _ := New(ft)
+	_, _, line, _ := runtime.Caller(0)
+	l.Info("here")
+	l.With().Str("k", "v").Warn("fields")
+	want := "mirror_test.go:" + strconv.Itoa(line+1) + ": "
+	want2 := "mirror_test.go:" + strconv.Itoa(line+2) + ": "
+	out := ft.out.String()
+	if len(ft.logs) != 0 || !hasLine(out, want, "msg=here") || !hasLine(out, want2, "msg=fields") {
+		t.Fatalf("caller attribution mismatch: %q %q", out, ft.logs)
+	}
+}
+
+// hasLine reports whether out has a line starting with prefix and ending with suffix.
+func hasLine(out, prefix, suffix string) bool {
+	for _, s := range strings.Split(out, "\n") {
+		if strings.HasPrefix(s, prefix) && strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"expvar"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/zxysilent/logs/internal/file"
+)
+
+// maxNamespaces bounds the namespace label set; further namespaces are
+// counted under otherNamespace.
+const (
+	maxNamespaces  = 256
+	otherNamespace = "_other"
+)
+
+// dropReason says why a record that passed the level check was not written.
+type dropReason int
+
+const (
+	dropSampling dropReason = iota // dropped by the sampler
+	dropDedup                      // suppressed as a repeat by WithDedup
+	dropHook                       // vetoed by a hook
+)
+
+// dropReasons names the reasons in the snapshot and the Prometheus label.
+var dropReasons = [...]string{dropSampling: "sampling", dropDedup: "dedup", dropHook: "hook"}
+
+// Metrics counts records per level and namespace, records dropped per reason,
+// bytes written, write errors and file rotations/deletions. One Metrics may
+// be shared by several Loggers and file writers; all methods are safe for
+// concurrent use.
+type Metrics struct {
+	levels  [4]atomic.Uint64
+	dropped [len(dropReasons)]atomic.Uint64
+	bytes   atomic.Uint64
+	errors  atomic.Uint64
+	mu      sync.RWMutex
+	ns      map[string]*atomic.Uint64
+	files   []*file.Writer
+}
+
+// NewMetrics creates an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{ns: make(map[string]*atomic.Uint64)}
+}
+
+// WithMetrics counts the Logger's records into m.
+func WithMetrics(m *Metrics) Option {
+	return func(c *config) { c.metrics = m }
+}
+
+// WithFileMetrics reports the file writer's rotations and deletions through m.
+func WithFileMetrics(m *Metrics) FileOption {
+	return func(fw *file.Writer) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.files = append(m.files, fw)
+	}
+}
+
+// record counts one record of lv in namespace trace.
+func (m *Metrics) record(lv Level, trace string) {
+	m.levels[levelIndex(lv)].Add(1)
+	m.mu.RLock()
+	n, ok := m.ns[trace]
+	if !ok && len(m.ns) >= maxNamespaces {
+		n, ok = m.ns[otherNamespace]
+	}
+	m.mu.RUnlock()
+	if !ok {
+		m.mu.Lock()
+		if n, ok = m.ns[trace]; !ok {
+			key := trace
+			if len(m.ns) >= maxNamespaces {
+				key = otherNamespace
+			}
+			if n = m.ns[key]; n == nil {
+				n = new(atomic.Uint64)
+				m.ns[key] = n
+			}
+		}
+		m.mu.Unlock()
+	}
+	n.Add(1)
+}
+
+// drop counts one record dropped for reason.
+func (m *Metrics) drop(reason dropReason) {
+	m.dropped[reason].Add(1)
+}
+
+// namespaceOf strips the trace id joined by Ctx from trace, so that
+// namespaces keep a bounded label set.
+func namespaceOf(ctx context.Context, trace string) string {
+	if ctx == nil {
+		return trace
+	}
+	tid := TraceOf(ctx)
+	switch {
+	case tid == "" || !strings.HasSuffix(trace, tid):
+		return trace
+	case len(trace) == len(tid):
+		return ""
+	case trace[len(trace)-len(tid)-1] == '.':
+		return trace[:len(trace)-len(tid)-1]
+	}
+	return trace
+}
+
+// MetricsSnapshot is a point-in-time copy of the counters.
+type MetricsSnapshot struct {
+	Records     map[string]uint64 `json:"records"`    // by level name
+	Namespaces  map[string]uint64 `json:"namespaces"` // by namespace ("" for none)
+	Dropped     map[string]uint64 `json:"dropped"`    // by reason: sampling, dedup, hook
+	Bytes       uint64            `json:"bytes"`
+	WriteErrors uint64            `json:"write_errors"`
+	Rotations   uint64            `json:"rotations"`
+	Deletions   uint64            `json:"deletions"`
+}
+
+// Snapshot returns the current counter values.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	s := MetricsSnapshot{
+		Records:     make(map[string]uint64, len(m.levels)),
+		Namespaces:  make(map[string]uint64),
+		Dropped:     make(map[string]uint64, len(dropReasons)),
+		Bytes:       m.bytes.Load(),
+		WriteErrors: m.errors.Load(),
+	}
+	for _, lv := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		s.Records[lv.String()] = m.levels[levelIndex(lv)].Load()
+	}
+	for i, reason := range dropReasons {
+		s.Dropped[reason] = m.dropped[i].Load()
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for k, n := range m.ns {
+		s.Namespaces[k] = n.Load()
+	}
+	for _, fw := range m.files {
+		s.Rotations += fw.Rotations()
+		s.Deletions += fw.Deletions()
+	}
+	return s
+}
+
+// Publish exports the counters as the expvar variable name.
+// Like expvar.Publish it panics if name is already registered.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return m.Snapshot() }))
+}
+
+// Handler returns an http.Handler rendering the counters in the Prometheus
+// text exposition format (version 0.0.4).
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		m.Snapshot().writeProm(bw)
+		bw.Flush()
+	})
+}
+
+// writeProm writes s in the Prometheus text format.
+func (s MetricsSnapshot) writeProm(w *bufio.Writer) {
+	family := func(name, help string) {
+		w.WriteString("# HELP " + name + " " + help + "\n# TYPE " + name + " counter\n")
+	}
+	sample := func(name, label, val string, v uint64) {
+		w.WriteString(name)
+		if label != "" {
+			w.WriteString("{" + label + "=\"" + escapeLabel(val) + "\"}")
+		}
+		w.WriteString(" " + strconv.FormatUint(v, 10) + "\n")
+	}
+	family("logs_records_total", "Records logged by level.")
+	for _, lv := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		sample("logs_records_total", "level", lv.String(), s.Records[lv.String()])
+	}
+	family("logs_namespace_records_total", "Records logged by namespace.")
+	keys := make([]string, 0, len(s.Namespaces))
+	for k := range s.Namespaces {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sample("logs_namespace_records_total", "namespace", k, s.Namespaces[k])
+	}
+	family("logs_dropped_records_total", "Records dropped by sampling, dedup or a hook veto, by reason.")
+	for _, reason := range dropReasons {
+		sample("logs_dropped_records_total", "reason", reason, s.Dropped[reason])
+	}
+	family("logs_written_bytes_total", "Bytes written to the output.")
+	sample("logs_written_bytes_total", "", "", s.Bytes)
+	family("logs_write_errors_total", "Records the output failed to write.")
+	sample("logs_write_errors_total", "", "", s.WriteErrors)
+	family("logs_file_rotations_total", "Log files moved to a backup name.")
+	sample("logs_file_rotations_total", "", "", s.Rotations)
+	family("logs_file_deletions_total", "Log file backups removed by maxage cleanup.")
+	sample("logs_file_deletions_total", "", "", s.Deletions)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a Prometheus label value.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
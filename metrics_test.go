@@ -0,0 +1,113 @@
+package logs
+
+import (
+	"context"
+	"expvar"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMetrics verifies level, namespace, byte and error counters.
+func TestMetrics(t *testing.T) {
+	m := NewMetrics()
+	out := &failWriter{}
+	l := New(out, WithHijack(false), WithLevel(LevelDebug), WithMetrics(m), WithFallback(nil))
+	l.Debug("a")
+	l.Trace("api").Info("b")
+	l.Trace("api").Ctx(TraceCtx(context.Background(), "k2m3n4p5")).Warnf("c %d", 1) // trace id is not a namespace
+	l.Ctx(TraceCtx(context.Background(), "x9")).Error("d")
+	out.fail = true
+	l.Error("e")
+	s := m.Snapshot()
+	if s.Records["DBG"] != 1 || s.Records["INF"] != 1 || s.Records["WRN"] != 1 || s.Records["ERR"] != 2 {
+		t.Fatalf("level counters mismatch: %v", s.Records)
+	}
+	if len(s.Namespaces) != 2 || s.Namespaces[""] != 3 || s.Namespaces["api"] != 2 {
+		t.Fatalf("namespace counters mismatch: %v", s.Namespaces)
+	}
+	if s.Bytes != uint64(out.buf.Len()) || s.WriteErrors != 1 {
+		t.Fatalf("bytes=%d (want %d) errors=%d", s.Bytes, out.buf.Len(), s.WriteErrors)
+	}
+}
+
+// TestMetricsNamespaceCap verifies namespaces beyond the cap share one label.
+func TestMetricsNamespaceCap(t *testing.T) {
+	m := NewMetrics()
+	l := New(io.Discard, WithHijack(false), WithMetrics(m))
+	for i := 0; i < maxNamespaces+10; i++ {
+		l.Trace(strings.Repeat("n", i+1)).Info("x")
+	}
+	s := m.Snapshot()
+	if len(s.Namespaces) != maxNamespaces+1 || s.Namespaces[otherNamespace] != 10 {
+		t.Fatalf("cap mismatch: %d namespaces, other=%d", len(s.Namespaces), s.Namespaces[otherNamespace])
+	}
+}
+
+// TestMetricsDropped verifies records dropped by sampling, dedup and hook
+// vetoes are counted per reason and not as written.
+func TestMetricsDropped(t *testing.T) {
+	m := NewMetrics()
+	veto := HookFunc(func(e *Entry) bool { return e.Message != "secret" })
+	l := New(io.Discard, WithHijack(false), WithMetrics(m), WithDedup(time.Minute), WithHooks(veto))
+	l.Info("secret")
+	l.Info("x")
+	l.Info("x")
+	l.Info("x")
+	sl := l.Sample(Sampling{First: 1})
+	for i := 0; i < 4; i++ {
+		sl.Warnf("burst %d", i)
+	}
+	s := m.Snapshot()
+	if s.Dropped["hook"] != 1 || s.Dropped["dedup"] != 2 || s.Dropped["sampling"] != 3 {
+		t.Fatalf("dropped counters mismatch: %v", s.Dropped)
+	}
+	if s.Records["INF"] != 2 || s.Records["WRN"] != 1 { // "x" and its repeat summary
+		t.Fatalf("level counters mismatch: %v", s.Records)
+	}
+}
+
+// TestMetricsExport verifies the Prometheus handler, expvar and file counters.
+func TestMetricsExport(t *testing.T) {
+	m := NewMetrics()
+	clk := &fakeClock{t: time.Date(2026, 5, 9, 23, 59, 59, 0, time.UTC)}
+	w, closer := NewFile(filepath.Join(t.TempDir(), "app.log"), WithConsole(false), WithFileClock(clk), WithFileMetrics(m))
+	defer closer()
+	l := New(w, WithHijack(false), WithClock(clk), WithMetrics(m))
+	l.Trace(`a"b`).Info("x")
+	clk.Advance(2 * time.Second)
+	l.Info("y") // rotates
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE logs_records_total counter\n",
+		"logs_records_total{level=\"INF\"} 2\n",
+		"logs_namespace_records_total{namespace=\"\"} 1\n",
+		"logs_namespace_records_total{namespace=\"a\\\"b\"} 1\n",
+		"logs_file_rotations_total 1\n",
+		"logs_write_errors_total 0\n",
+		"# TYPE logs_dropped_records_total counter\n",
+		"logs_dropped_records_total{reason=\"sampling\"} 0\n",
+		"logs_dropped_records_total{reason=\"dedup\"} 0\n",
+		"logs_dropped_records_total{reason=\"hook\"} 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("content type %q", ct)
+	}
+
+	m.Publish("logs_test_metrics")
+	v := expvar.Get("logs_test_metrics").String()
+	if !strings.Contains(v, `"INF":2`) || !strings.Contains(v, `"rotations":1`) ||
+		!strings.Contains(v, `"dropped":{"dedup":0,"hook":0,"sampling":0}`) {
+		t.Fatalf("expvar mismatch: %s", v)
+	}
+}
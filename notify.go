@@ -0,0 +1,80 @@
+package logs
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/zxysilent/logs/internal/notify"
+)
+
+// NotifyOption is a configuration item for NewNotifier.
+type NotifyOption func(*notify.Writer)
+
+// WithNotifyLevel sets the minimum level that is aggregated (default LevelError).
+func WithNotifyLevel(lv Level) NotifyOption {
+	return func(w *notify.Writer) { w.SetLevel(int(lv)) }
+}
+
+// WithNotifyWindow sets the aggregation window (default 1m).
+func WithNotifyWindow(d time.Duration) NotifyOption {
+	return func(w *notify.Writer) { w.SetWindow(d) }
+}
+
+// WithNotifyTemplate sets the text/template rendering each request body.
+// Fields: .Text .Level .Msg .Caller .Count .First .Last .Fields .Traces;
+// {{json .X}} emits a JSON-encoded value. Panics on an invalid template.
+func WithNotifyTemplate(text string) NotifyOption {
+	return func(w *notify.Writer) {
+		if err := w.SetTemplate(text); err != nil {
+			panic("illegal notify template: " + err.Error())
+		}
+	}
+}
+
+// WithNotifyRateLimit allows at most n POSTs per interval (default 10 per minute; n=0 disables).
+// Summaries over the limit are held, and their records are counted in the next summary of the group.
+// Close sends held summaries regardless of the limit.
+func WithNotifyRateLimit(n int, per time.Duration) NotifyOption {
+	return func(w *notify.Writer) { w.SetRateLimit(n, per) }
+}
+
+// WithNotifyQuietHours suppresses notifications between from and to, given
+// as local "15:04" clock times; a range past midnight ("22:00", "07:00") is allowed.
+// Records logged meanwhile are summarized after the quiet hours end, or on Close.
+// Panics on an invalid clock time.
+func WithNotifyQuietHours(from, to string) NotifyOption {
+	return func(w *notify.Writer) { w.SetQuietHours(clockOffset(from), clockOffset(to)) }
+}
+
+// WithNotifyHeader sets an HTTP header sent with every request.
+func WithNotifyHeader(key, val string) NotifyOption {
+	return func(w *notify.Writer) { w.SetHeader(key, val) }
+}
+
+// WithNotifyClient sets the HTTP client.
+func WithNotifyClient(c *http.Client) NotifyOption { return func(w *notify.Writer) { w.SetClient(c) } }
+
+// clockOffset parses "15:04" into an offset since midnight.
+func clockOffset(s string) time.Duration {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		panic("illegal notify clock time: " + s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+// NewNotifier opens a webhook notifier, returning the Writer and its close handle.
+// Records at or above the notify level are grouped by msg and caller over a
+// window, and one summary per group (count, first/last time, sample fields,
+// trace ids) is POSTed to url. Tee it next to the main output:
+//
+//	nw, closeFn := logs.NewNotifier(url)
+//	l := logs.New(io.MultiWriter(fw, nw))
+func NewNotifier(url string, opts ...NotifyOption) (io.Writer, func() error) {
+	w := notify.New(url)
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w, w.Close
+}
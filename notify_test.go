@@ -0,0 +1,58 @@
+package logs
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestNewNotifier verifies repeated errors are posted as a single summary.
+func TestNewNotifier(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	w, closeFn := NewNotifier(srv.URL,
+		WithNotifyWindow(time.Hour),
+		WithNotifyTemplate(`{{.Msg}}|{{.Count}}`),
+		WithNotifyRateLimit(5, time.Minute),
+		WithNotifyQuietHours("00:00", "00:00"))
+	l := New(w, WithHijack(false))
+	for i := 0; i < 5; i++ {
+		l.With().Int("i", i).Error("db down")
+	}
+	l.Warn("not notified")
+	if err := closeFn(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 || bodies[0] != "db down|5" {
+		t.Fatalf("summary mismatch: %v", bodies)
+	}
+}
+
+// TestNotifyOptionPanics verifies invalid templates and clock times are rejected.
+func TestNotifyOptionPanics(t *testing.T) {
+	for _, opt := range []NotifyOption{WithNotifyTemplate("{{"), WithNotifyQuietHours("25:00", "07:00")} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.HasPrefix(r.(string), "illegal notify") {
+					t.Fatalf("expected illegal notify panic, got %v", r)
+				}
+			}()
+			_, closeFn := NewNotifier("http://127.0.0.1:0", opt)
+			closeFn()
+		}()
+	}
+}
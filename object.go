@@ -0,0 +1,510 @@
+package logs
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zxysilent/logs/internal/textenc"
+)
+
+// ObjectMarshaler lets a type log itself field by field, without reflection.
+type ObjectMarshaler interface {
+	MarshalLogObject(enc ObjectEncoder) error
+}
+
+// ObjectMarshalerFunc adapts a function to the ObjectMarshaler interface.
+type ObjectMarshalerFunc func(enc ObjectEncoder) error
+
+// MarshalLogObject returns f(enc).
+func (f ObjectMarshalerFunc) MarshalLogObject(enc ObjectEncoder) error { return f(enc) }
+
+// ArrayMarshaler lets a type log itself element by element, without reflection.
+type ArrayMarshaler interface {
+	MarshalLogArray(enc ArrayEncoder) error
+}
+
+// ArrayMarshalerFunc adapts a function to the ArrayMarshaler interface.
+type ArrayMarshalerFunc func(enc ArrayEncoder) error
+
+// MarshalLogArray returns f(enc).
+func (f ArrayMarshalerFunc) MarshalLogArray(enc ArrayEncoder) error { return f(enc) }
+
+// ObjectEncoder receives the fields of an ObjectMarshaler. In logfmt output
+// they are flattened into dotted keys (user.addr.city=x); inside arrays they
+// are nested JSON objects.
+type ObjectEncoder interface {
+	AddString(key, val string)
+	AddInt(key string, val int)
+	AddInt64(key string, val int64)
+	AddUint64(key string, val uint64)
+	AddFloat64(key string, val float64)
+	AddBool(key string, val bool)
+	AddDuration(key string, val time.Duration)
+	AddTime(key string, val time.Time)
+	AddAny(key string, val any) // JSON-marshaled
+	AddObject(key string, val ObjectMarshaler) error
+	AddArray(key string, val ArrayMarshaler) error
+}
+
+// ArrayEncoder receives the elements of an ArrayMarshaler. Arrays are
+// written as JSON arrays.
+type ArrayEncoder interface {
+	AppendString(val string)
+	AppendInt(val int)
+	AppendInt64(val int64)
+	AppendUint64(val uint64)
+	AppendFloat64(val float64)
+	AppendBool(val bool)
+	AppendDuration(val time.Duration)
+	AppendTime(val time.Time)
+	AppendAny(val any) // JSON-marshaled
+	AppendObject(val ObjectMarshaler) error
+	AppendArray(val ArrayMarshaler) error
+}
+
+// textArray is an ArrayMarshaler of free text, such as stack frames or error
+// messages, whose JSON is quoted as a logfmt string when it contains spaces.
+type textArray interface {
+	ArrayMarshaler
+	freeText()
+}
+
+// marshalPanic reports a panicking marshaler as a !PANIC placeholder.
+type marshalPanic struct{ r any }
+
+func (p *marshalPanic) Error() string { return textenc.PanicValue(p.r) }
+
+// marshalObject calls v.MarshalLogObject, turning a panic into an error.
+func marshalObject(enc ObjectEncoder, v ObjectMarshaler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &marshalPanic{r}
+		}
+	}()
+	return v.MarshalLogObject(enc)
+}
+
+// marshalArray calls v.MarshalLogArray, turning a panic into an error.
+func marshalArray(enc ArrayEncoder, v ArrayMarshaler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &marshalPanic{r}
+		}
+	}()
+	return v.MarshalLogArray(enc)
+}
+
+// errorText returns err.Error(), recovering a panicking Error method.
+func errorText(err error) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = (&marshalPanic{r}).Error()
+		}
+	}()
+	return err.Error()
+}
+
+// stringerText returns val.String(), recovering a panicking String method.
+func stringerText(val fmt.Stringer) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = textenc.PanicValue(r)
+		}
+	}()
+	return val.String()
+}
+
+// Encoders are pooled: handing them to marshalers through an interface
+// makes them escape.
+var (
+	flatPool = sync.Pool{New: func() any { return &flatEncoder{path: make([]byte, 0, 64)} }}
+	jsonPool = sync.Pool{New: func() any { return new(jsonEncoder) }}
+)
+
+// putObject appends v under key, flattened into dotted logfmt keys. A
+// marshaling error (or panic) is appended as a keyError field. A redacted
+// key masks the object as a whole, from its JSON form; the flattened keys
+// are redacted one by one.
+func putObject(c *config, dst []byte, key string, v ObjectMarshaler) []byte {
+	var err error
+	if c.redact.lookup(key).mask != nil {
+		e := jsonPool.Get().(*jsonEncoder)
+		var k keyRule
+		e.buf, k = c.putKey(dst, key)
+		start := len(e.buf)
+		err = e.object(v)
+		dst = k.apply(c.enc, e.buf, start)
+		e.buf = nil
+		jsonPool.Put(e)
+	} else {
+		e := flatPool.Get().(*flatEncoder)
+		e.buf, e.c = dst, c
+		err = e.AddObject(key, v)
+		dst = e.buf
+		e.buf, e.path, e.c = nil, e.path[:0], nil
+		flatPool.Put(e)
+	}
+	if err != nil {
+		dst = c.enc.PutStringQuote(c.enc.PutKey(dst, key+"Error"), c.redact.scrub(errorText(err)))
+	}
+	return dst
+}
+
+// putArray appends v under key as a JSON array. A marshaling error (or
+// panic) is appended as a keyError field.
+func putArray(c *config, dst []byte, key string, v ArrayMarshaler) []byte {
+	e := jsonPool.Get().(*jsonEncoder)
+	var k keyRule
+	e.buf, k = c.putKey(dst, key)
+	e.r = c.redact
+	start := len(e.buf)
+	err := e.array(v)
+	dst = k.apply(c.enc, c.enc.QuoteRaw(e.buf, start), start)
+	e.buf, e.r = nil, nil
+	jsonPool.Put(e)
+	if err != nil {
+		dst = c.enc.PutStringQuote(c.enc.PutKey(dst, key+"Error"), c.redact.scrub(errorText(err)))
+	}
+	return dst
+}
+
+// flatEncoder writes object fields as logfmt pairs with dotted keys, masking
+// the values of redacted keys.
+type flatEncoder struct {
+	buf  []byte
+	path []byte // prefix of the object being encoded, e.g. "user.addr."
+	c    *config
+}
+
+// key appends the logfmt key path+key and returns its redaction decision.
+func (e *flatEncoder) key(key string) keyRule {
+	n := len(e.path)
+	e.path = append(e.path, key...)
+	e.buf = e.c.enc.PutKeyBytes(e.buf, e.path)
+	k := e.c.redact.lookupBytes(e.path)
+	e.path = e.path[:n]
+	return k
+}
+
+func (e *flatEncoder) AddString(key, val string) {
+	if k := e.key(key); k.mask != nil {
+		val = k.mask(val)
+	} else {
+		val = e.c.redact.scrub(val)
+	}
+	e.buf = e.c.enc.PutStringQuote(e.buf, val)
+}
+
+func (e *flatEncoder) AddInt(key string, val int) {
+	k, start := e.key(key), len(e.buf)
+	e.buf = k.apply(e.c.enc, textenc.PutInt(e.buf, val), start)
+}
+
+func (e *flatEncoder) AddInt64(key string, val int64) {
+	k, start := e.key(key), len(e.buf)
+	e.buf = k.apply(e.c.enc, textenc.PutInt64(e.buf, val), start)
+}
+
+func (e *flatEncoder) AddUint64(key string, val uint64) {
+	k, start := e.key(key), len(e.buf)
+	e.buf = k.apply(e.c.enc, textenc.PutUint64(e.buf, val), start)
+}
+
+func (e *flatEncoder) AddFloat64(key string, val float64) {
+	k, start := e.key(key), len(e.buf)
+	e.buf = k.apply(e.c.enc, textenc.PutFloat64(e.buf, val), start)
+}
+
+func (e *flatEncoder) AddBool(key string, val bool) {
+	k, start := e.key(key), len(e.buf)
+	e.buf = k.apply(e.c.enc, textenc.PutBool(e.buf, val), start)
+}
+
+func (e *flatEncoder) AddDuration(key string, val time.Duration) {
+	k, start := e.key(key), len(e.buf)
+	e.buf = k.apply(e.c.enc, e.c.enc.PutDuration(e.buf, val), start)
+}
+
+func (e *flatEncoder) AddTime(key string, val time.Time) {
+	k, start := e.key(key), len(e.buf)
+	e.buf = k.apply(e.c.enc, textenc.PutTime(e.buf, val), start)
+}
+
+// AddAny writes val as JSON, masking members at redacted paths inside it.
+func (e *flatEncoder) AddAny(key string, val any) {
+	if e.c.redact == nil {
+		e.key(key)
+		e.buf = e.c.enc.PutAny(e.buf, val)
+		return
+	}
+	e.buf = putAnyField(e.c, e.buf, string(e.path)+key, val)
+}
+
+// AddObject flattens val under key; an empty object is written as key={}.
+// A redacted key masks the object as a whole, from its JSON form.
+func (e *flatEncoder) AddObject(key string, val ObjectMarshaler) error {
+	if val == nil {
+		k, start := e.key(key), len(e.buf)
+		e.buf = k.apply(e.c.enc, textenc.PutNil(e.buf), start)
+		return nil
+	}
+	n, start := len(e.path), len(e.buf)
+	e.path = append(e.path, key...)
+	if k := e.c.redact.lookupBytes(e.path); k.mask != nil {
+		e.path = e.path[:n]
+		e.key(key)
+		j := jsonPool.Get().(*jsonEncoder)
+		j.buf = e.buf
+		vstart := len(e.buf)
+		err := j.object(val)
+		e.buf = k.apply(e.c.enc, j.buf, vstart)
+		j.buf = nil
+		jsonPool.Put(j)
+		return err
+	}
+	e.path = append(e.path, '.')
+	err := marshalObject(e, val)
+	e.path = e.path[:n]
+	if len(e.buf) == start {
+		e.key(key)
+		e.buf = append(e.buf, "{}"...)
+	}
+	return err
+}
+
+// AddArray writes val as a JSON array; arrays of free text are quoted when
+// they contain spaces.
+func (e *flatEncoder) AddArray(key string, val ArrayMarshaler) error {
+	k := e.key(key)
+	start := len(e.buf)
+	j := jsonPool.Get().(*jsonEncoder)
+	j.buf, j.r = e.buf, e.c.redact
+	err := j.array(val)
+	if _, ok := val.(textArray); ok {
+		e.buf = e.c.enc.QuoteText(j.buf, start)
+	} else {
+		e.buf = e.c.enc.QuoteRaw(j.buf, start)
+	}
+	e.buf = k.apply(e.c.enc, e.buf, start)
+	j.buf, j.r = nil, nil
+	jsonPool.Put(j)
+	return err
+}
+
+// jsonEncoder writes nested objects and arrays as JSON, scrubbing strings
+// when r is set.
+type jsonEncoder struct {
+	buf []byte
+	r   *redactor
+}
+
+// comma separates a new element from the previous one.
+func (e *jsonEncoder) comma() {
+	if n := len(e.buf); n > 0 && e.buf[n-1] != '[' && e.buf[n-1] != '{' {
+		e.buf = append(e.buf, ',')
+	}
+}
+
+// key appends a JSON object key.
+func (e *jsonEncoder) key(key string) {
+	e.comma()
+	e.buf = append(textenc.PutJSONString(e.buf, key), ':')
+}
+
+// object appends val as a JSON object.
+func (e *jsonEncoder) object(val ObjectMarshaler) error {
+	if val == nil {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	e.buf = append(e.buf, '{')
+	err := marshalObject(e, val)
+	e.buf = append(e.buf, '}')
+	return err
+}
+
+// array appends val as a JSON array.
+func (e *jsonEncoder) array(val ArrayMarshaler) error {
+	if val == nil {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	e.buf = append(e.buf, '[')
+	err := marshalArray(e, val)
+	e.buf = append(e.buf, ']')
+	return err
+}
+
+func (e *jsonEncoder) AddString(key, val string)                 { e.key(key); e.putString(val) }
+func (e *jsonEncoder) AddInt(key string, val int)                { e.key(key); e.putInt64(int64(val)) }
+func (e *jsonEncoder) AddInt64(key string, val int64)            { e.key(key); e.putInt64(val) }
+func (e *jsonEncoder) AddUint64(key string, val uint64)          { e.key(key); e.putUint64(val) }
+func (e *jsonEncoder) AddFloat64(key string, val float64)        { e.key(key); e.putFloat64(val) }
+func (e *jsonEncoder) AddBool(key string, val bool)              { e.key(key); e.putBool(val) }
+func (e *jsonEncoder) AddDuration(key string, val time.Duration) { e.key(key); e.putDuration(val) }
+func (e *jsonEncoder) AddTime(key string, val time.Time)         { e.key(key); e.putTime(val) }
+func (e *jsonEncoder) AddAny(key string, val any)                { e.key(key); e.putAny(val) }
+func (e *jsonEncoder) AddObject(key string, val ObjectMarshaler) error {
+	e.key(key)
+	return e.object(val)
+}
+func (e *jsonEncoder) AddArray(key string, val ArrayMarshaler) error {
+	e.key(key)
+	return e.array(val)
+}
+
+func (e *jsonEncoder) AppendString(val string)          { e.comma(); e.putString(val) }
+func (e *jsonEncoder) AppendInt(val int)                { e.comma(); e.putInt64(int64(val)) }
+func (e *jsonEncoder) AppendInt64(val int64)            { e.comma(); e.putInt64(val) }
+func (e *jsonEncoder) AppendUint64(val uint64)          { e.comma(); e.putUint64(val) }
+func (e *jsonEncoder) AppendFloat64(val float64)        { e.comma(); e.putFloat64(val) }
+func (e *jsonEncoder) AppendBool(val bool)              { e.comma(); e.putBool(val) }
+func (e *jsonEncoder) AppendDuration(val time.Duration) { e.comma(); e.putDuration(val) }
+func (e *jsonEncoder) AppendTime(val time.Time)         { e.comma(); e.putTime(val) }
+func (e *jsonEncoder) AppendAny(val any)                { e.comma(); e.putAny(val) }
+func (e *jsonEncoder) AppendObject(val ObjectMarshaler) error {
+	e.comma()
+	return e.object(val)
+}
+func (e *jsonEncoder) AppendArray(val ArrayMarshaler) error {
+	e.comma()
+	return e.array(val)
+}
+
+func (e *jsonEncoder) putString(val string)          { e.buf = textenc.PutJSONString(e.buf, e.r.scrub(val)) }
+func (e *jsonEncoder) putInt64(val int64)            { e.buf = textenc.PutInt64(e.buf, val) }
+func (e *jsonEncoder) putUint64(val uint64)          { e.buf = textenc.PutUint64(e.buf, val) }
+func (e *jsonEncoder) putFloat64(val float64)        { e.buf = textenc.PutFloat64(e.buf, val) }
+func (e *jsonEncoder) putBool(val bool)              { e.buf = textenc.PutBool(e.buf, val) }
+func (e *jsonEncoder) putDuration(val time.Duration) { e.buf = putJSONDuration(e.buf, val) }
+func (e *jsonEncoder) putTime(val time.Time)         { e.buf = putJSONTime(e.buf, val) }
+func (e *jsonEncoder) putAny(val any)                { e.buf = textenc.PutJSON(e.buf, val) }
+
+// putJSONDuration appends d as a JSON string ("1.5s").
+func putJSONDuration(dst []byte, d time.Duration) []byte {
+	return textenc.PutJSONString(dst, d.String())
+}
+
+// putJSONTime appends t as a JSON string in the record time layout.
+func putJSONTime(dst []byte, t time.Time) []byte {
+	return append(textenc.PutTime(append(dst, '"'), t), '"')
+}
+
+// putJSONString appends val, scrubbed, as a JSON string.
+func (r *redactor) putJSONString(dst []byte, val string) []byte {
+	return textenc.PutJSONString(dst, r.scrub(val))
+}
+
+// putJSONError appends err, scrubbed, as a JSON string (nil renders as null).
+func (r *redactor) putJSONError(dst []byte, err error) []byte {
+	if err == nil {
+		return append(dst, "null"...)
+	}
+	return textenc.PutJSONString(dst, r.scrub(errorText(err)))
+}
+
+// putSlice appends vals as a JSON array using put for each element.
+func putSlice[T any](enc textenc.Encoder, dst []byte, vals []T, put func([]byte, T) []byte) []byte {
+	start := len(dst)
+	return enc.QuoteRaw(appendSlice(dst, vals, put), start)
+}
+
+// putTextSlice is putSlice for free text, such as strings and error
+// messages: the array is quoted when an element contains spaces.
+func putTextSlice[T any](enc textenc.Encoder, dst []byte, vals []T, put func([]byte, T) []byte) []byte {
+	start := len(dst)
+	return enc.QuoteText(appendSlice(dst, vals, put), start)
+}
+
+// appendSlice appends vals as a JSON array using put for each element.
+func appendSlice[T any](dst []byte, vals []T, put func([]byte, T) []byte) []byte {
+	dst = append(dst, '[')
+	for i := range vals {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = put(dst, vals[i])
+	}
+	return append(dst, ']')
+}
+
+// Object adds an ObjectMarshaler field, flattened into dotted keys
+// (key.sub=val). A marshaling error is added as a keyError field.
+func (s *fielder) Object(key string, val ObjectMarshaler) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	*s.attr = putObject(s.cfg, *s.attr, key, val)
+	return s
+}
+
+// Array adds an ArrayMarshaler field as a JSON array.
+// A marshaling error is added as a keyError field.
+func (s *fielder) Array(key string, val ArrayMarshaler) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	*s.attr = putArray(s.cfg, *s.attr, key, val)
+	return s
+}
+
+// Strs adds a []string field as a JSON array.
+func (s *fielder) Strs(key string, vals []string) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	dst, k := s.cfg.putKey(*s.attr, key)
+	*s.attr = k.apply(s.cfg.enc, putTextSlice(s.cfg.enc, dst, vals, s.cfg.redact.putJSONString), len(dst))
+	return s
+}
+
+// Ints adds an []int field as a JSON array.
+func (s *fielder) Ints(key string, vals []int) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	dst, k := s.cfg.putKey(*s.attr, key)
+	*s.attr = k.apply(s.cfg.enc, putSlice(s.cfg.enc, dst, vals, textenc.PutInt), len(dst))
+	return s
+}
+
+// Floats adds a []float64 field as a JSON array.
+func (s *fielder) Floats(key string, vals []float64) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	dst, k := s.cfg.putKey(*s.attr, key)
+	*s.attr = k.apply(s.cfg.enc, putSlice(s.cfg.enc, dst, vals, textenc.PutFloat64), len(dst))
+	return s
+}
+
+// Errs adds an []error field as a JSON array of messages (nil renders as null).
+func (s *fielder) Errs(key string, errs []error) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	dst, k := s.cfg.putKey(*s.attr, key)
+	*s.attr = k.apply(s.cfg.enc, putTextSlice(s.cfg.enc, dst, errs, s.cfg.redact.putJSONError), len(dst))
+	return s
+}
+
+// Durs adds a []time.Duration field as a JSON array.
+func (s *fielder) Durs(key string, vals []time.Duration) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	dst, k := s.cfg.putKey(*s.attr, key)
+	*s.attr = k.apply(s.cfg.enc, putSlice(s.cfg.enc, dst, vals, putJSONDuration), len(dst))
+	return s
+}
+
+// Times adds a []time.Time field as a JSON array.
+func (s *fielder) Times(key string, vals []time.Time) *fielder {
+	if s.attr == nil {
+		return s
+	}
+	dst, k := s.cfg.putKey(*s.attr, key)
+	*s.attr = k.apply(s.cfg.enc, putSlice(s.cfg.enc, dst, vals, putJSONTime), len(dst))
+	return s
+}
@@ -0,0 +1,151 @@
+package logs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zxysilent/logs/internal/logfmt"
+)
+
+type testAddr struct{ City, Zip string }
+
+func (a testAddr) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("city", a.City)
+	enc.AddString("zip", a.Zip)
+	return nil
+}
+
+type testUser struct {
+	Name  string
+	Age   int
+	Addr  *testAddr
+	Roles testRoles
+}
+
+type testRoles []string
+
+func (rs *testRoles) MarshalLogArray(enc ArrayEncoder) error {
+	for _, r := range *rs {
+		enc.AppendString(r)
+	}
+	return nil
+}
+
+func (u *testUser) MarshalLogObject(enc ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	enc.AddInt("age", u.Age)
+	if u.Addr != nil {
+		enc.AddObject("addr", u.Addr)
+	}
+	return enc.AddArray("roles", &u.Roles)
+}
+
+type testUsers []*testUser
+
+func (us *testUsers) MarshalLogArray(enc ArrayEncoder) error {
+	for _, u := range *us {
+		if err := enc.AppendObject(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestObjectFlatten verifies objects flatten into dotted keys and arrays nest as JSON.
+func TestObjectFlatten(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	u := &testUser{Name: "bob smith", Age: 30, Addr: &testAddr{City: "x", Zip: "1"}, Roles: []string{"a", "b c"}}
+	l.With().Object("user", u).Info("login")
+	l.With().Array("users", &testUsers{u, {Name: "eve"}}).Info("list")
+	l.With().Object("empty", ObjectMarshalerFunc(func(ObjectEncoder) error { return nil })).Object("none", nil).Info("edge")
+	out := buf.String()
+	for _, want := range []string{
+		`user.name="bob smith" user.age=30 user.addr.city=x user.addr.zip=1 user.roles=["a","b c"] msg=login`,
+		`users=[{"name":"bob smith","age":30,"addr":{"city":"x","zip":"1"},"roles":["a","b c"]},{"name":"eve","age":0,"roles":[]}] msg=list`,
+		`empty={} none=nil msg=edge`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %s in:\n%s", want, out)
+		}
+	}
+	rec := logfmt.Decode([]byte(strings.SplitN(out, "\n", 2)[0]))
+	if len(rec.Fields) != 5 || rec.Fields[0].Val != "bob smith" || rec.Fields[4].Val != `["a","b c"]` {
+		t.Fatalf("decoded fields mismatch: %+v", rec.Fields)
+	}
+}
+
+// TestArrayHelpers verifies the slice helpers render JSON arrays.
+func TestArrayHelpers(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	l.With().Strs("s", []string{"a", `q"`}).Ints("i", []int{1, -2}).Floats("f", []float64{0.5}).
+		Errs("e", []error{errors.New("x"), nil}).Durs("d", []time.Duration{time.Second}).Times("t", []time.Time{at}).
+		Strs("none", nil).Info("arr")
+	want := `s=["a","q\""] i=[1,-2] f=[0.5] e=["x",null] d=["1s"] t=["2024-01-02T03:04:05.000"] none=[] msg=arr`
+	if !strings.Contains(buf.String(), want) {
+		t.Fatalf("helpers mismatch:\n got: %s\nwant: %s", buf.String(), want)
+	}
+}
+
+// TestArrayHelpersText verifies string and error arrays with spaces are quoted.
+func TestArrayHelpersText(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	l.With().Strs("tags", []string{"a b", "c"}).Errs("e", []error{errors.New("not found")}).Info("arr")
+	want := `tags="[\"a b\",\"c\"]" e="[\"not found\"]" msg=arr`
+	if !strings.Contains(buf.String(), want) {
+		t.Fatalf("text arrays mismatch:\n got: %s\nwant: %s", buf.String(), want)
+	}
+}
+
+// TestMarshalerErrors verifies marshaler errors and panics become keyError fields.
+func TestMarshalerErrors(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	l.With().Object("o", ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+		enc.AddBool("ok", true)
+		return errors.New("half done")
+	})).Array("a", ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+		enc.AppendInt(1)
+		panic("boom")
+	})).Info("bad")
+	want := `o.ok=true oError="half done" a=[1] aError="!PANIC(string: boom)" msg=bad`
+	if !strings.Contains(buf.String(), want) {
+		t.Fatalf("error fields mismatch:\n got: %s\nwant: %s", buf.String(), want)
+	}
+}
+
+// TestObjectField verifies the Object and Array Field constructors.
+func TestObjectField(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	l.Log(LevelInfo, "f", Object("addr", testAddr{City: "x"}), Array("ids", ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+		enc.AppendUint64(7)
+		return nil
+	})))
+	if want := "addr.city=x addr.zip= ids=[7] msg=f"; !strings.Contains(buf.String(), want) {
+		t.Fatalf("Field mismatch:\n got: %s\nwant: %s", buf.String(), want)
+	}
+}
+
+// TestObjectAllocs verifies marshalers and slice helpers do not allocate.
+func TestObjectAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items under the race detector")
+	}
+	l := New(io.Discard, WithHijack(false))
+	u := &testUser{Name: "bob", Age: 30, Addr: &testAddr{City: "x"}}
+	us := testUsers{u}
+	strs, durs := []string{"a", "b"}, []time.Duration{time.Second}
+	if n := testing.AllocsPerRun(100, func() {
+		l.With().Object("user", u).Array("users", &us).Strs("s", strs).Durs("d", durs).Info("x")
+	}); n != 0 {
+		t.Fatalf("Object/Array allocate %.0f times", n)
+	}
+}
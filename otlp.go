@@ -0,0 +1,68 @@
+package logs
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/zxysilent/logs/internal/otlp"
+)
+
+// OTLPOption is a configuration item for NewOTLP.
+type OTLPOption func(*otlp.Writer)
+
+// WithOTLPBatch sets the number of records that triggers an immediate POST (default 512).
+func WithOTLPBatch(n int) OTLPOption { return func(w *otlp.Writer) { w.SetBatch(n) } }
+
+// WithOTLPInterval sets the maximum time records wait before being POSTed (default 5s).
+func WithOTLPInterval(d time.Duration) OTLPOption {
+	return func(w *otlp.Writer) { w.SetInterval(d) }
+}
+
+// WithOTLPHeader sets an HTTP header sent with every export request.
+func WithOTLPHeader(key, val string) OTLPOption {
+	return func(w *otlp.Writer) { w.SetHeader(key, val) }
+}
+
+// WithOTLPService sets the service.name resource attribute.
+func WithOTLPService(name string) OTLPOption {
+	return func(w *otlp.Writer) { w.SetResource("service.name", name) }
+}
+
+// WithOTLPResource adds a resource attribute.
+func WithOTLPResource(key, val string) OTLPOption {
+	return func(w *otlp.Writer) { w.SetResource(key, val) }
+}
+
+// WithOTLPClock sets the source of observed times, normally the Clock given
+// to WithClock.
+func WithOTLPClock(clk Clock) OTLPOption {
+	return func(w *otlp.Writer) {
+		if clk == nil {
+			w.SetClock(nil)
+		} else {
+			w.SetClock(clk.Now)
+		}
+	}
+}
+
+// WithOTLPClient sets the HTTP client used for export.
+func WithOTLPClient(c *http.Client) OTLPOption { return func(w *otlp.Writer) { w.SetClient(c) } }
+
+// NewOTLP opens an OTLP/HTTP JSON log exporter posting to endpoint
+// (e.g. http://collector:4318/v1/logs), returning the Writer and its close handle.
+// Each record becomes a LogRecord: level → severityNumber, msg → body,
+// custom fields → attributes. A W3C trace id (and span id) in the trace value
+// becomes traceId (and spanId); the rest of it is the namespace attribute.
+// Ids generated by TraceCtx are not W3C ids: the exporter tells them apart
+// from the namespace only by their shape (8 base-32 characters, at least one
+// a digit) and exports them as a trace_id attribute. Custom ids given to
+// TraceCtx, and generated ids without a digit, stay in the namespace.
+// The close handle flushes queued records before returning.
+func NewOTLP(endpoint string, opts ...OTLPOption) (io.Writer, func() error) {
+	w := otlp.New(endpoint)
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w, w.Close
+}
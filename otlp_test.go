@@ -0,0 +1,53 @@
+package logs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestNewOTLP verifies a Logger writing through NewOTLP posts OTLP JSON to the endpoint.
+func TestNewOTLP(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	clk := ClockFunc(func() time.Time { return time.Unix(1700000000, 0) })
+	w, closeFn := NewOTLP(srv.URL, WithOTLPService("svc"), WithOTLPBatch(10), WithOTLPHeader("X-Key", "k"), WithOTLPClock(clk))
+	l := New(w, WithHijack(false), WithClock(clk))
+	ctx := TraceCtx(context.Background(), "0af7651916cd43dd8448eb211c80319c")
+	l.Ctx(ctx).Str("user", "alice").Error("login failed")
+	if err := closeFn(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Fatalf("want 1 export request, got %d", len(bodies))
+	}
+	got := bodies[0]
+	for _, want := range []string{
+		`"severityNumber":17`,
+		`"body":{"stringValue":"login failed"}`,
+		`"traceId":"0af7651916cd43dd8448eb211c80319c"`,
+		`"observedTimeUnixNano":"1700000000000000000"`,
+		`"key":"user","value":{"stringValue":"alice"}`,
+		`"key":"service.name","value":{"stringValue":"svc"}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("export body missing %s: %s", want, got)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package logs
 
 import (
+	"context"
 	"fmt"
-	"runtime"
+	"io"
+	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,128 +34,246 @@ func lastSep(file string, seps []string) int {
 	return -1
 }
 
-// putCaller writes "caller=file:line" into buf using runtime.Callers + FuncForPC.FileLine
-// (no allocation: pcs does not escape and FileLine does not allocate).
-// skip is the full frame count passed to runtime.Callers (computed by the caller).
-func (c *config) putCaller(buf *buffer, skip int) {
-	var pcs [1]uintptr
-	file := "###"
-	line := 0
-	if runtime.Callers(skip, pcs[:]) >= 1 {
-		// PC-1 points into the CALL instruction (CallersFrames semantics).
-		if fn := runtime.FuncForPC(pcs[0] - 1); fn != nil {
-			file, line = fn.FileLine(pcs[0] - 1)
-			if slash := lastSep(file, c.sep); slash >= 0 {
-				file = file[slash:]
-			}
+// print writes a log record.
+func (c *config) print(ctx context.Context, trace string, lv Level, caller bool, skip int, attr *buffer, args ...any) {
+	args, fields := splitFields(args)
+	var e *Entry
+	if len(c.hooks) > 0 {
+		if e = c.runHooks(ctx, trace, lv, sprint(args)); e == nil {
+			return
 		}
+		defer putEntry(e)
 	}
-	*buf = textenc.PutString(textenc.PutKeyRaw(*buf, callerFieldName), file)
-	*buf = append(*buf, ':')
-	*buf = strconv.AppendInt(*buf, int64(line), 10)
-}
-
-// print writes a log record.
-func (c *config) print(trace string, lv Level, caller bool, attr *buffer, args ...any) {
 	buf := getb()
 	defer putb(buf)
 	*buf = textenc.PutBegin(*buf)
-	*buf = textenc.PutTime(textenc.PutKeyRaw(*buf, timeFieldName), time.Now())
-	*buf = textenc.PutString(textenc.PutKeyRaw(*buf, levelFieldName), lv.String())
+	*buf = textenc.PutTime(textenc.PutKeyRaw(*buf, timeFieldName), c.now())
+	*buf = c.enc.PutString(textenc.PutKeyRaw(*buf, levelFieldName), lv.String())
 	if trace != "" {
-		*buf = textenc.PutString(textenc.PutKeyRaw(*buf, traceFieldName), trace)
+		*buf = c.enc.PutString(textenc.PutKeyRaw(*buf, traceFieldName), trace)
 	}
+	var lay layout
+	lay.attr = len(*buf)
 	if caller {
-		c.putCaller(buf, c.skip+callerBaseSkip)
+		c.putCaller(buf, c.skip+skip+callerBaseSkip)
 	}
 	if attr != nil && len(*attr) >= 1 {
 		*buf = textenc.PutDelim(*buf)
 		*buf = append(*buf, *attr...)
 	}
+	lay.hook = len(*buf)
+	putHookAttr(buf, e)
+	lay.fields = len(*buf)
+	for _, f := range fields {
+		*buf = f.(Field).put(*buf, c)
+	}
+	if lv >= c.stackLevel && !isSummary(ctx) {
+		c.putStack(buf, c.skip+skip+callerBaseSkip)
+	}
+	lay.msg = len(*buf)
 	n := len(args)
-	if n == 1 {
+	if n >= 1 && c.redact.scrubbing() {
+		*buf = c.enc.PutStringQuote(textenc.PutKeyRaw(*buf, mesgFieldName), c.redact.scrub(sprint(args)))
+	} else if n == 1 {
 		key := textenc.PutKeyRaw(*buf, mesgFieldName)
-		switch v := args[0].(type) {
-		case string:
-			*buf = textenc.PutStringQuote(key, v)
-		case []byte:
-			*buf = textenc.PutBytesQuote(key, v)
-		case bool:
-			*buf = textenc.PutBool(key, v)
-		case int:
-			*buf = textenc.PutInt(key, v)
-		case int8:
-			*buf = textenc.PutInt8(key, v)
-		case int16:
-			*buf = textenc.PutInt16(key, v)
-		case int32:
-			*buf = textenc.PutInt32(key, v)
-		case int64:
-			*buf = textenc.PutInt64(key, v)
-		case uint:
-			*buf = textenc.PutUint(key, v)
-		case uint8:
-			*buf = textenc.PutUint8(key, v)
-		case uint16:
-			*buf = textenc.PutUint16(key, v)
-		case uint32:
-			*buf = textenc.PutUint32(key, v)
-		case uint64:
-			*buf = textenc.PutUint64(key, v)
-		case float32:
-			*buf = textenc.PutFloat32(key, v)
-		case float64:
-			*buf = textenc.PutFloat64(key, v)
-		case fmt.Stringer:
-			*buf = textenc.PutStringQuote(key, v.String())
-		default:
-			*buf = textenc.PutStringQuote(key, fmt.Sprint(v))
+		var ok bool
+		if *buf, ok = putTyped(c.enc, key, args[0]); !ok {
+			*buf = c.enc.PutStringQuote(key, sprint(args))
 		}
 	} else if n > 1 {
-		*buf = textenc.PutStringQuote(textenc.PutKeyRaw(*buf, mesgFieldName), fmt.Sprint(args...))
+		*buf = c.enc.PutStringQuote(textenc.PutKeyRaw(*buf, mesgFieldName), sprint(args))
 	}
 	*buf = textenc.PutEnd(*buf)
 	*buf = textenc.PutBreak(*buf)
-	c.out.Write(*buf)
+	c.write(ctx, trace, lv, lay, *buf)
+}
+
+// putTyped appends v using the typed fast path for its type; ok is false
+// (and dst unchanged) when v has none.
+func putTyped(enc textenc.Encoder, dst []byte, v any) ([]byte, bool) {
+	switch v := v.(type) {
+	case string:
+		return enc.PutStringQuote(dst, v), true
+	case []byte:
+		return enc.PutBytesQuote(dst, v), true
+	case bool:
+		return textenc.PutBool(dst, v), true
+	case int:
+		return textenc.PutInt(dst, v), true
+	case int8:
+		return textenc.PutInt8(dst, v), true
+	case int16:
+		return textenc.PutInt16(dst, v), true
+	case int32:
+		return textenc.PutInt32(dst, v), true
+	case int64:
+		return textenc.PutInt64(dst, v), true
+	case uint:
+		return textenc.PutUint(dst, v), true
+	case uint8:
+		return textenc.PutUint8(dst, v), true
+	case uint16:
+		return textenc.PutUint16(dst, v), true
+	case uint32:
+		return textenc.PutUint32(dst, v), true
+	case uint64:
+		return textenc.PutUint64(dst, v), true
+	case float32:
+		return textenc.PutFloat32(dst, v), true
+	case float64:
+		return textenc.PutFloat64(dst, v), true
+	case fmt.Stringer:
+		return enc.PutStringer(dst, v), true
+	case error:
+		return enc.PutError(dst, v), true
+	}
+	return dst, false
 }
 
 // printf writes a formatted log record.
-func (c *config) printf(trace string, lv Level, caller bool, attr *buffer, format string, args ...any) {
+func (c *config) printf(ctx context.Context, trace string, lv Level, caller bool, skip int, attr *buffer, format string, args ...any) {
+	msg := format
+	if len(args) >= 1 {
+		msg = sprintf(format, args)
+	}
+	var e *Entry
+	if len(c.hooks) > 0 {
+		if e = c.runHooks(ctx, trace, lv, msg); e == nil {
+			return
+		}
+		defer putEntry(e)
+	}
 	buf := getb()
 	defer putb(buf)
 	*buf = textenc.PutBegin(*buf)
-	*buf = textenc.PutTime(textenc.PutKeyRaw(*buf, timeFieldName), time.Now())
-	*buf = textenc.PutString(textenc.PutKeyRaw(*buf, levelFieldName), lv.String())
+	*buf = textenc.PutTime(textenc.PutKeyRaw(*buf, timeFieldName), c.now())
+	*buf = c.enc.PutString(textenc.PutKeyRaw(*buf, levelFieldName), lv.String())
 	if trace != "" {
-		*buf = textenc.PutString(textenc.PutKeyRaw(*buf, traceFieldName), trace)
+		*buf = c.enc.PutString(textenc.PutKeyRaw(*buf, traceFieldName), trace)
 	}
+	var lay layout
+	lay.attr = len(*buf)
 	if caller {
-		c.putCaller(buf, c.skip+callerBaseSkip)
+		c.putCaller(buf, c.skip+skip+callerBaseSkip)
 	}
 	if attr != nil && len(*attr) >= 1 {
 		*buf = textenc.PutDelim(*buf)
 		*buf = append(*buf, *attr...)
 	}
-	if len(args) >= 1 {
-		*buf = textenc.PutStringQuote(textenc.PutKeyRaw(*buf, mesgFieldName), fmt.Sprintf(format, args...))
-	} else {
-		*buf = textenc.PutStringQuote(textenc.PutKeyRaw(*buf, mesgFieldName), format)
+	lay.hook = len(*buf)
+	putHookAttr(buf, e)
+	lay.fields = len(*buf)
+	if lv >= c.stackLevel {
+		c.putStack(buf, c.skip+skip+callerBaseSkip)
 	}
+	lay.msg = len(*buf)
+	*buf = c.enc.PutStringQuote(textenc.PutKeyRaw(*buf, mesgFieldName), c.redact.scrub(msg))
 	*buf = textenc.PutEnd(*buf)
 	*buf = textenc.PutBreak(*buf)
-	c.out.Write(*buf)
+	c.write(ctx, trace, lv, lay, *buf)
+}
+
+// printw writes a record with typed fields and alternating key/value fields after attr.
+func (c *config) printw(ctx context.Context, trace string, lv Level, caller bool, skip int, attr *buffer, fields []Field, msg string, kv ...any) {
+	var e *Entry
+	if len(c.hooks) > 0 {
+		if e = c.runHooks(ctx, trace, lv, msg); e == nil {
+			return
+		}
+		defer putEntry(e)
+	}
+	buf := getb()
+	defer putb(buf)
+	*buf = textenc.PutBegin(*buf)
+	*buf = textenc.PutTime(textenc.PutKeyRaw(*buf, timeFieldName), c.now())
+	*buf = c.enc.PutString(textenc.PutKeyRaw(*buf, levelFieldName), lv.String())
+	if trace != "" {
+		*buf = c.enc.PutString(textenc.PutKeyRaw(*buf, traceFieldName), trace)
+	}
+	var lay layout
+	lay.attr = len(*buf)
+	if caller {
+		c.putCaller(buf, c.skip+skip+callerBaseSkip)
+	}
+	if attr != nil && len(*attr) >= 1 {
+		*buf = textenc.PutDelim(*buf)
+		*buf = append(*buf, *attr...)
+	}
+	lay.hook = len(*buf)
+	putHookAttr(buf, e)
+	lay.fields = len(*buf)
+	*buf = putFields(*buf, fields, c)
+	*buf = putKV(*buf, kv, c)
+	if lv >= c.stackLevel {
+		c.putStack(buf, c.skip+skip+callerBaseSkip)
+	}
+	lay.msg = len(*buf)
+	*buf = c.enc.PutStringQuote(textenc.PutKeyRaw(*buf, mesgFieldName), c.redact.scrub(msg))
+	*buf = textenc.PutEnd(*buf)
+	*buf = textenc.PutBreak(*buf)
+	c.write(ctx, trace, lv, lay, *buf)
+}
+
+// badKey marks a dangling or non-string key in key/value pairs.
+const badKey = "!BADKEY"
+
+// putKV appends alternating key/value pairs; a Field stands for a whole pair.
+// A non-string key, or a final key without a value, is written as the value
+// of a !BADKEY field. Values of redacted keys are masked.
+func putKV(dst []byte, kv []any, c *config) []byte {
+	for i := 0; i < len(kv); {
+		if f, ok := kv[i].(Field); ok {
+			dst = f.put(dst, c)
+			i++
+			continue
+		}
+		key, ok := kv[i].(string)
+		if !ok || i == len(kv)-1 {
+			dst = putAnyValue(c.enc, textenc.PutKeyRaw(dst, badKey), kv[i])
+			i++
+			continue
+		}
+		if v, ok := kv[i+1].(string); ok {
+			dst = putStrField(c, dst, key, v)
+		} else if k := c.redact.lookup(key); k.mask != nil || k.nested {
+			dst = putAnyField(c, dst, key, kv[i+1])
+		} else if s, ok := c.redact.scrubValue(kv[i+1]); ok {
+			dst = c.enc.PutStringQuote(c.enc.PutKey(dst, key), s)
+		} else {
+			dst = putAnyValue(c.enc, c.enc.PutKey(dst, key), kv[i+1])
+		}
+		i += 2
+	}
+	return dst
+}
+
+// putAnyValue appends v using its typed fast path, falling back to JSON.
+func putAnyValue(enc textenc.Encoder, dst []byte, v any) []byte {
+	if out, ok := putTyped(enc, dst, v); ok {
+		return out
+	}
+	return enc.PutAny(dst, v)
 }
 
 // printb writes a log record with a byte slice message.
 func (c *config) printb(trace string, lv Level, caller bool, attr *buffer, msg []byte) {
+	var e *Entry
+	if len(c.hooks) > 0 {
+		if e = c.runHooks(nil, trace, lv, string(msg)); e == nil {
+			return
+		}
+		defer putEntry(e)
+	}
 	buf := getb()
 	defer putb(buf)
 	*buf = textenc.PutBegin(*buf)
-	*buf = textenc.PutTime(textenc.PutKeyRaw(*buf, timeFieldName), time.Now())
-	*buf = textenc.PutString(textenc.PutKeyRaw(*buf, levelFieldName), lv.String())
+	*buf = textenc.PutTime(textenc.PutKeyRaw(*buf, timeFieldName), c.now())
+	*buf = c.enc.PutString(textenc.PutKeyRaw(*buf, levelFieldName), lv.String())
 	if trace != "" {
-		*buf = textenc.PutString(textenc.PutKeyRaw(*buf, traceFieldName), trace)
+		*buf = c.enc.PutString(textenc.PutKeyRaw(*buf, traceFieldName), trace)
 	}
+	var lay layout
+	lay.attr = len(*buf)
 	if caller {
 		c.putCaller(buf, c.skip+writerBaseSkip)
 	}
@@ -160,12 +281,188 @@ func (c *config) printb(trace string, lv Level, caller bool, attr *buffer, msg [
 		*buf = textenc.PutDelim(*buf)
 		*buf = append(*buf, *attr...)
 	}
-	if len(msg) >= 1 {
-		*buf = textenc.PutBytesQuote(textenc.PutKeyRaw(*buf, mesgFieldName), msg)
+	lay.hook = len(*buf)
+	putHookAttr(buf, e)
+	lay.fields = len(*buf)
+	if lv >= c.stackLevel {
+		c.putStack(buf, c.skip+writerBaseSkip)
+	}
+	lay.msg = len(*buf)
+	if len(msg) >= 1 && c.redact.scrubbing() {
+		*buf = c.enc.PutStringQuote(textenc.PutKeyRaw(*buf, mesgFieldName), c.redact.scrub(string(msg)))
+	} else if len(msg) >= 1 {
+		*buf = c.enc.PutBytesQuote(textenc.PutKeyRaw(*buf, mesgFieldName), msg)
 	}
 	*buf = textenc.PutEnd(*buf)
 	*buf = textenc.PutBreak(*buf)
-	c.out.Write(*buf)
+	c.write(nil, trace, lv, lay, *buf)
+}
+
+// fmtPanic is how fmt reports a panic in a String, Error or Format method.
+const fmtPanic = "(PANIC="
+
+// sprint formats print-style arguments as a message. fmt recovers panics in
+// the methods of args itself; only a message in which it reported one is
+// formatted again, with the arguments wrapped by safeArgs.
+func sprint(args []any) string {
+	if len(args) == 1 {
+		if s, ok := args[0].(string); ok {
+			return s
+		}
+	}
+	if s := fmt.Sprint(args...); !strings.Contains(s, fmtPanic) {
+		return s
+	}
+	return fmt.Sprint(safeArgs(args)...)
+}
+
+// sprintf is sprint for printf-style arguments.
+func sprintf(format string, args []any) string {
+	if s := fmt.Sprintf(format, args...); !strings.Contains(s, fmtPanic) {
+		return s
+	}
+	return fmt.Sprintf(format, safeArgs(args)...)
+}
+
+// safeArgs returns args with every value whose String, Error, Format or
+// GoString method fmt may call wrapped in a safeArg. fmt recovers a panic in
+// such a method itself but renders it as %!v(PANIC=...); safeArg renders it
+// as !PANIC(type: value), like the typed fields. fmt handles %T and %p before
+// calling methods, so wrapped values would report safeArg there; that is why
+// args are only wrapped after fmt reported a panic. args is returned as is
+// when nothing needs wrapping.
+func safeArgs(args []any) []any {
+	var out []any
+	for i, a := range args {
+		if !hasMethods(a) {
+			continue
+		}
+		if out == nil {
+			out = make([]any, len(args))
+			copy(out, args)
+		}
+		out[i] = safeArg{a}
+	}
+	if out == nil {
+		return args
+	}
+	return out
+}
+
+// hasMethods reports whether fmt may call a method of v. Values of string
+// kind are left alone: wrapping them would change how fmt.Sprint spaces its
+// operands.
+func hasMethods(v any) bool {
+	switch v.(type) {
+	case fmt.Formatter, fmt.Stringer, error, fmt.GoStringer:
+		return reflect.TypeOf(v).Kind() != reflect.String
+	}
+	return false
+}
+
+// safeArg formats v as fmt would, recovering a panic in its methods.
+type safeArg struct{ v any }
+
+// Format implements fmt.Formatter.
+func (a safeArg) Format(f fmt.State, verb rune) {
+	defer func() {
+		if r := recover(); r != nil {
+			io.WriteString(f, textenc.PanicValue(r))
+		}
+	}()
+	if v, ok := a.v.(fmt.Formatter); ok {
+		v.Format(f, verb)
+		return
+	}
+	if verb == 'v' && f.Flag('#') {
+		if v, ok := a.v.(fmt.GoStringer); ok {
+			io.WriteString(f, v.GoString())
+			return
+		}
+	} else {
+		switch verb {
+		case 'v', 's', 'x', 'X', 'q':
+			switch v := a.v.(type) {
+			case error:
+				fmt.Fprintf(f, fmt.FormatString(f, verb), v.Error())
+				return
+			case fmt.Stringer:
+				fmt.Fprintf(f, fmt.FormatString(f, verb), v.String())
+				return
+			}
+		}
+	}
+	fmt.Fprintf(f, fmt.FormatString(f, verb), a.v)
+}
+
+// layout holds the offsets of the parts of a record between its trace and
+// its msg, which a repeat summary copies.
+type layout struct {
+	attr   int // caller and attr fields
+	hook   int // fields added by hooks
+	fields int // fields of the call and stack
+	msg    int // msg field
+}
+
+// write sends a finished record to the output, suppressing duplicates if
+// enabled, and counts it in the metrics once it is written.
+func (c *config) write(ctx context.Context, trace string, lv Level, lay layout, p []byte) {
+	if c.dup != nil && !isSummary(ctx) {
+		if !c.dup.write(c, ctx, trace, lv, lay, p) {
+			if c.metrics != nil {
+				c.metrics.drop(dropDedup)
+			}
+			return
+		}
+	} else {
+		c.emit(p)
+	}
+	if c.metrics != nil {
+		c.metrics.record(lv, namespaceOf(ctx, trace))
+	}
+}
+
+// emit writes p to the output, reporting failures.
+func (c *config) emit(p []byte) {
+	n, err := c.out.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if c.metrics != nil {
+		c.metrics.bytes.Add(uint64(n))
+	}
+	if err != nil {
+		c.fail(err, p)
+	}
+}
+
+// diagInterval bounds how often write failures are reported on the fallback.
+const diagInterval = time.Minute
+
+// fail counts a failed write, notifies the handler and hands the record to
+// the fallback writer, preceded by a diagnostic at most once per diagInterval.
+func (c *config) fail(err error, p []byte) {
+	total := c.failed.Add(1)
+	if c.metrics != nil {
+		c.metrics.errors.Add(1)
+	}
+	if c.onError != nil {
+		c.onError(err, p)
+	}
+	if c.fallback == nil {
+		return
+	}
+	now := c.now().UnixNano()
+	last := c.diagAt.Load()
+	if (last == 0 || now-last >= int64(diagInterval)) && c.diagAt.CompareAndSwap(last, now) {
+		diag := append(make([]byte, 0, 96), "logs: write failed: "...)
+		diag = append(diag, err.Error()...)
+		diag = append(diag, " ("...)
+		diag = strconv.AppendUint(diag, total, 10)
+		diag = append(diag, " failed writes)\n"...)
+		c.fallback.Write(diag)
+	}
+	c.fallback.Write(p)
 }
 
 const maxBufferSize = 512
@@ -213,8 +510,12 @@ func putfl(fl *fielder) {
 	}
 	putb(fl.attr)
 	fl.attr = nil
+	fl.smp = nil
+	fl.ctx = nil
+	fl.lv = LevelInfo
 	fl.trace = ""
 	fl.caller = false
 	fl.skip = false
+	fl.depth = 0
 	fpool.Put(fl)
 }
@@ -0,0 +1,378 @@
+package logs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/zxysilent/logs/internal/textenc"
+)
+
+// maxRedactKeys bounds the per-key cache of redaction decisions.
+const maxRedactKeys = 4096
+
+// Mask renders the replacement for a redacted value. val is the value as
+// text: strings unquoted, other values as encoded (JSON for Any).
+type Mask func(val string) string
+
+// MaskStars replaces values with ***.
+func MaskStars() Mask { return maskStars }
+
+func maskStars(string) string { return "***" }
+
+// MaskHash replaces values with "sha256:" and the first 16 hex digits of
+// their HMAC-SHA256 under key, so equal values can be correlated without
+// being revealed.
+func MaskHash(key []byte) Mask {
+	key = append([]byte(nil), key...)
+	return func(val string) string {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(val))
+		return "sha256:" + hex.EncodeToString(h.Sum(nil)[:8])
+	}
+}
+
+// MaskPartial keeps the first head and last tail characters of values and
+// stars the rest: ab***yz. Values too short to hide anything become ***.
+func MaskPartial(head, tail int) Mask {
+	if head < 0 || tail < 0 {
+		panic("illegal logs mask")
+	}
+	return func(val string) string {
+		n := utf8.RuneCountInString(val)
+		if n <= head+tail {
+			return "***"
+		}
+		i := 0
+		for k := 0; k < head; k++ {
+			_, size := utf8.DecodeRuneInString(val[i:])
+			i += size
+		}
+		j := len(val)
+		for k := 0; k < tail; k++ {
+			_, size := utf8.DecodeLastRuneInString(val[:j])
+			j -= size
+		}
+		return val[:i] + "***" + val[j:]
+	}
+}
+
+// WithRedact masks the values of fields whose keys match any of patterns.
+// Each pattern is a comma-separated list of case-insensitive keys where *
+// matches any run of characters, e.g. "password,*token*,authorization".
+// Patterns with dots are paths: they match dotted keys, and members nested
+// inside Any values ("user.password", "*.secret"). Patterns without dots also
+// match the last element of dotted keys. The first matching rule wins; a nil
+// mask means MaskStars. Redacting costs one cached lookup per key.
+func WithRedact(mask Mask, patterns ...string) Option {
+	if mask == nil {
+		mask = maskStars
+	}
+	return func(c *config) {
+		if c.redact == nil {
+			c.redact = new(redactor)
+		}
+		for _, p := range patterns {
+			for _, s := range strings.Split(p, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					c.redact.rules = append(c.redact.rules, redactRule{
+						pattern: lowerASCII(s),
+						path:    strings.IndexByte(s, '.') >= 0,
+						mask:    mask,
+					})
+				}
+			}
+		}
+	}
+}
+
+type redactRule struct {
+	pattern string // lower-cased
+	path    bool   // contains a dot
+	mask    Mask
+}
+
+// keyRule is the redaction decision for one field key.
+type keyRule struct {
+	mask   Mask // non-nil: the whole value is masked
+	nested bool // path rules may match inside the value
+}
+
+// redactor applies the WithRedact and WithScrub rules. Decisions are cached
+// per key, at most maxRedactKeys of them, so lookups of known keys do not
+// allocate.
+type redactor struct {
+	rules     []redactRule
+	detectors []Detector // WithScrub
+	keys      map[string]keyRule
+	mu        sync.RWMutex
+}
+
+// putKey writes key= into dst and returns the redaction decision for key.
+func (c *config) putKey(dst []byte, key string) ([]byte, keyRule) {
+	return c.enc.PutKey(dst, key), c.redact.lookup(key)
+}
+
+// lookup returns the cached decision for key.
+func (r *redactor) lookup(key string) keyRule {
+	if r == nil {
+		return keyRule{}
+	}
+	r.mu.RLock()
+	k, ok := r.keys[key]
+	r.mu.RUnlock()
+	if ok {
+		return k
+	}
+	k = keyRule{mask: r.match(key, false)}
+	if k.mask == nil {
+		k.nested = r.nestedUnder(key)
+	}
+	r.mu.Lock()
+	if r.keys == nil {
+		r.keys = make(map[string]keyRule)
+	}
+	if len(r.keys) < maxRedactKeys {
+		r.keys[key] = k
+	}
+	r.mu.Unlock()
+	return k
+}
+
+// lookupBytes is lookup for a key held in a byte slice, such as a dotted
+// object path; it does not allocate once the key is cached.
+func (r *redactor) lookupBytes(key []byte) keyRule {
+	if r == nil {
+		return keyRule{}
+	}
+	r.mu.RLock()
+	k, ok := r.keys[string(key)]
+	r.mu.RUnlock()
+	if ok {
+		return k
+	}
+	return r.lookup(string(key))
+}
+
+// match returns the mask of the first rule matching path. Inside values
+// (nested) only path rules apply.
+func (r *redactor) match(path string, nested bool) Mask {
+	last := path[strings.LastIndexByte(path, '.')+1:]
+	for _, rule := range r.rules {
+		switch {
+		case rule.path:
+			if glob(rule.pattern, path, false) {
+				return rule.mask
+			}
+		case !nested:
+			if glob(rule.pattern, path, false) || glob(rule.pattern, last, false) {
+				return rule.mask
+			}
+		}
+	}
+	return nil
+}
+
+// nestedUnder reports whether a path rule could match inside the value of key.
+func (r *redactor) nestedUnder(key string) bool {
+	for _, rule := range r.rules {
+		if rule.path && glob(rule.pattern, key+".", true) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply masks the value encoded at dst[start:] when k says so.
+func (k keyRule) apply(enc textenc.Encoder, dst []byte, start int) []byte {
+	if k.mask == nil {
+		return dst
+	}
+	return enc.PutStringQuote(dst[:start], k.mask(valueText(dst[start:])))
+}
+
+// valueText returns an encoded value as text, unquoting quoted values.
+func valueText(b []byte) string {
+	if len(b) > 1 && b[0] == '"' {
+		var s string
+		if json.Unmarshal(b, &s) == nil {
+			return s
+		}
+	}
+	return string(b)
+}
+
+// putField writes key=val with put, masking val when the key is redacted.
+func putField[T any](c *config, dst []byte, key string, val T, put func([]byte, T) []byte) []byte {
+	dst, k := c.putKey(dst, key)
+	if k.mask == nil {
+		return put(dst, val)
+	}
+	if s, ok := any(val).(string); ok {
+		return c.enc.PutStringQuote(dst, k.mask(s))
+	}
+	return k.apply(c.enc, put(dst, val), len(dst))
+}
+
+// putStrField is putField for strings, also scrubbing unmasked values.
+func putStrField(c *config, dst []byte, key, val string) []byte {
+	dst, k := c.putKey(dst, key)
+	if k.mask != nil {
+		return c.enc.PutStringQuote(dst, k.mask(val))
+	}
+	return c.enc.PutStringQuote(dst, c.redact.scrub(val))
+}
+
+// putTextField is putField for errors and Stringers, also scrubbing the text
+// of unmasked values.
+func putTextField[T any](c *config, dst []byte, key string, val T, put func([]byte, T) []byte) []byte {
+	dst, k := c.putKey(dst, key)
+	if k.mask != nil {
+		return k.apply(c.enc, put(dst, val), len(dst))
+	}
+	if s, ok := c.redact.scrubValue(any(val)); ok {
+		return c.enc.PutStringQuote(dst, s)
+	}
+	return put(dst, val)
+}
+
+// putAnyField is putField for JSON-marshaled values, also masking members
+// at redacted paths inside them.
+func putAnyField(c *config, dst []byte, key string, val any) []byte {
+	if !c.redact.lookup(key).nested {
+		return putField(c, dst, key, val, c.enc.PutAny)
+	}
+	data, err := marshalJSON(val)
+	if err != nil {
+		return putField(c, dst, key, val, c.enc.PutAny)
+	}
+	buf := getb()
+	defer putb(buf)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if *buf, err = c.redact.redactJSON(*buf, dec, key); err != nil {
+		// encoding/json produced it, so this does not happen; fail closed
+		return c.enc.PutStringQuote(c.enc.PutKey(dst, key), "***")
+	}
+	return c.enc.PutRaw(c.enc.PutKey(dst, key), *buf)
+}
+
+// marshalJSON is json.Marshal reporting panics as errors.
+func marshalJSON(val any) (data []byte, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &marshalPanic{p}
+		}
+	}()
+	return json.Marshal(val)
+}
+
+// redactJSON copies the next JSON value of dec, found at path, into dst,
+// masking object members whose paths match a path rule.
+func (r *redactor) redactJSON(dst []byte, dec *json.Decoder, path string) ([]byte, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return dst, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		dst = append(dst, byte(t))
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			if t == '[' {
+				if dst, err = r.redactJSON(dst, dec, path); err != nil {
+					return dst, err
+				}
+				continue
+			}
+			tok, err = dec.Token()
+			if err != nil {
+				return dst, err
+			}
+			name, _ := tok.(string)
+			dst = append(textenc.PutJSONString(dst, name), ':')
+			sub := path + "." + name
+			if mask := r.match(sub, true); mask != nil {
+				var raw json.RawMessage
+				if err = dec.Decode(&raw); err != nil {
+					return dst, err
+				}
+				dst = textenc.PutJSONString(dst, mask(valueText(raw)))
+				continue
+			}
+			if dst, err = r.redactJSON(dst, dec, sub); err != nil {
+				return dst, err
+			}
+		}
+		if _, err = dec.Token(); err != nil {
+			return dst, err
+		}
+		if t == '[' {
+			return append(dst, ']'), nil
+		}
+		return append(dst, '}'), nil
+	case string:
+		return textenc.PutJSONString(dst, t), nil
+	case json.Number:
+		return append(dst, t...), nil
+	case bool:
+		return strconv.AppendBool(dst, t), nil
+	default:
+		return append(dst, "null"...), nil
+	}
+}
+
+// glob reports whether s matches pattern (lower-cased), ignoring ASCII case;
+// * matches any run of characters. With prefix, it reports whether some
+// string starting with s matches.
+func glob(pattern, s string, prefix bool) bool {
+	p, i := 0, 0
+	star, next := -1, 0
+	for i < len(s) {
+		if p < len(pattern) && pattern[p] == '*' {
+			star, next = p, i
+			p++
+			continue
+		}
+		if p < len(pattern) && pattern[p] == lowerByte(s[i]) {
+			p++
+			i++
+			continue
+		}
+		if star < 0 {
+			return false
+		}
+		p, next = star+1, next+1
+		i = next
+	}
+	if prefix {
+		return true
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+func lowerByte(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func lowerASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		b[i] = lowerByte(c)
+	}
+	return string(b)
+}
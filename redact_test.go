@@ -0,0 +1,161 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zxysilent/logs/internal/logfmt"
+)
+
+// fieldsOf decodes the fields of a single record.
+func fieldsOf(line string) map[string]string {
+	m := make(map[string]string)
+	for _, f := range logfmt.Decode([]byte(line)).Fields {
+		m[f.Key] = f.Val
+	}
+	return m
+}
+
+// TestRedactKeys verifies exact, glob and case-insensitive keys on every entry point.
+func TestRedactKeys(t *testing.T) {
+	var buf bytes.Buffer
+	hook := HookFunc(func(e *Entry) bool { e.Str("hook_token", "h"); return true })
+	l := New(&buf, WithHijack(false), WithHooks(hook), WithRedact(nil, "password, *token*", "Authorization"))
+	g := l.With().Str("Password", "preset").Group()
+	g.With().Str("access_token", "t").Str("authorization", "Bearer x").Int("TOKEN_ID", 7).
+		Str("user.password", "p").Errs("my_tokens", []error{errors.New("e")}).Str("id", "1").Info("fluent")
+	l.Infow("sugar", "refresh_token", 42, "name", "n")
+	l.Log(LevelInfo, "typed", String("password", "p"), Int("csrf_token", 1), Bool("ok", true))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("want 3 records:\n%s", buf.String())
+	}
+	masked := [][]string{
+		{"Password", "access_token", "authorization", "TOKEN_ID", "user.password", "my_tokens", "hook_token"},
+		{"refresh_token", "hook_token"},
+		{"password", "csrf_token", "hook_token"},
+	}
+	plain := []map[string]string{{"id": "1"}, {"name": "n"}, {"ok": "true"}}
+	for i, line := range lines {
+		fs := fieldsOf(line)
+		for _, k := range masked[i] {
+			if fs[k] != "***" {
+				t.Errorf("record %d: %s=%q, want ***", i, k, fs[k])
+			}
+		}
+		for k, v := range plain[i] {
+			if fs[k] != v {
+				t.Errorf("record %d: %s=%q, want %q", i, k, fs[k], v)
+			}
+		}
+	}
+}
+
+// TestRedactMasks verifies the built-in masks.
+func TestRedactMasks(t *testing.T) {
+	h1, h2 := MaskHash([]byte("k1")), MaskHash([]byte("k2"))
+	if a, b := h1("secret"), h1("secret"); a != b || !strings.HasPrefix(a, "sha256:") || len(a) != len("sha256:")+16 {
+		t.Fatalf("hash not stable: %q %q", a, b)
+	}
+	if h1("secret") == h2("secret") || h1("secret") == h1("other") {
+		t.Fatal("hash ignores the key or the value")
+	}
+	p := MaskPartial(2, 2)
+	for in, want := range map[string]string{"abcdefgh": "ab***gh", "abcd": "***", "": "***", "密码是一二三": "密码***二三"} {
+		if got := p(in); got != want {
+			t.Errorf("MaskPartial(%q) = %q, want %q", in, got, want)
+		}
+	}
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithRedact(MaskPartial(0, 4), "card"), WithRedact(h1, "email"))
+	l.With().Str("card", "4111 1111 1111 1234").Int("email", 5).Info("x")
+	fs := fieldsOf(buf.String())
+	if fs["card"] != "***1234" || fs["email"] != h1("5") {
+		t.Fatalf("masks mismatch: %s", buf.String())
+	}
+}
+
+type redactUser struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// TestRedactNested verifies path patterns inside Any values.
+func TestRedactNested(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithRedact(nil, "*.password", "req.headers.authorization"))
+	req := map[string]any{
+		"headers": map[string]string{"Authorization": "Bearer x", "Accept": "json"},
+		"users":   []redactUser{{"a", "pa"}, {"b", "pb"}},
+		"n":       1.5,
+	}
+	l.With().Any("req", req).Any("user", redactUser{"c", "pc"}).Any("password", "top").Info("x")
+	l.Infow("kv", "req", req)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		fs := fieldsOf(line)
+		var got struct {
+			Headers map[string]string `json:"headers"`
+			Users   []redactUser      `json:"users"`
+			N       float64           `json:"n"`
+		}
+		if err := json.Unmarshal([]byte(fs["req"]), &got); err != nil {
+			t.Fatalf("bad req %q: %v", fs["req"], err)
+		}
+		if got.Headers["Authorization"] != "***" || got.Headers["Accept"] != "json" || got.N != 1.5 ||
+			len(got.Users) != 2 || got.Users[1] != (redactUser{"b", "***"}) {
+			t.Fatalf("req mismatch: %s", fs["req"])
+		}
+		if u, ok := fs["user"]; ok && u != `{"name":"c","password":"***"}` {
+			t.Fatalf("struct order or mask mismatch: %s", u)
+		}
+		if p, ok := fs["password"]; ok && p != "top" {
+			t.Fatalf("path pattern matched a top-level key: %s", line)
+		}
+	}
+}
+
+// TestRedactObject verifies keys flattened from ObjectMarshalers are redacted
+// by bare and path patterns, through fielder.Object and the Object field.
+func TestRedactObject(t *testing.T) {
+	u := ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+		enc.AddString("name", "bob")
+		enc.AddString("password", "hunter2")
+		enc.AddInt("pin", 1234)
+		return enc.AddObject("creds", ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+			enc.AddString("key", "k1")
+			return nil
+		}))
+	})
+	for _, patterns := range [][]string{{"password", "pin", "user.creds"}, {"user.password", "*.pin", "user.creds"}} {
+		var buf bytes.Buffer
+		l := New(&buf, WithHijack(false), WithRedact(nil, patterns...))
+		l.With().Object("user", u).Info("m")
+		l.Log(LevelInfo, "m", Object("user", u))
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			fs := fieldsOf(line)
+			if fs["user.password"] != "***" || fs["user.pin"] != "***" || fs["user.creds"] != "***" || fs["user.name"] != "bob" {
+				t.Fatalf("%v: object not redacted: %s", patterns, line)
+			}
+			if strings.Contains(line, "hunter2") || strings.Contains(line, "k1") {
+				t.Fatalf("%v: secret leaked: %s", patterns, line)
+			}
+		}
+	}
+}
+
+// TestRedactAllocs verifies keys outside the policy stay allocation free.
+func TestRedactAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items under the race detector")
+	}
+	l := New(io.Discard, WithHijack(false), WithRedact(nil, "password,*token*"))
+	if n := testing.AllocsPerRun(100, func() {
+		l.With().Str("user", "u").Int("id", 1).Str("password", "p").Info("x")
+	}); n != 0 {
+		t.Fatalf("redaction allocates %.0f times", n)
+	}
+}
@@ -0,0 +1,210 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zxysilent/logs/internal/logfmt"
+)
+
+// ringKeepAlive is the interval of SSE comment lines that keep proxies from closing idle streams.
+const ringKeepAlive = 15 * time.Second
+
+var _ io.Writer = (*Ring)(nil)
+
+// Ring is an io.Writer that keeps the last n records in memory and serves
+// them over HTTP. Slots are reused, so a steady-state Write does not allocate
+// unless a live-tail client is connected.
+type Ring struct {
+	mu    sync.Mutex
+	slots [][]byte
+	next  uint64 // total records written; next slot is next % len(slots)
+	subs  map[chan []byte]struct{}
+}
+
+// NewRing creates a Ring holding the last n records (n < 1 is treated as 1).
+func NewRing(n int) *Ring {
+	if n < 1 {
+		n = 1
+	}
+	return &Ring{slots: make([][]byte, n), subs: make(map[chan []byte]struct{})}
+}
+
+// Write stores a copy of p, evicting the oldest record when full, and
+// forwards it to live-tail subscribers (slow subscribers miss records).
+func (r *Ring) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.next % uint64(len(r.slots))
+	r.slots[i] = append(r.slots[i][:0], p...)
+	r.next++
+	if len(r.subs) > 0 {
+		cp := append([]byte(nil), p...)
+		for ch := range r.subs {
+			select {
+			case ch <- cp:
+			default:
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// Records returns copies of the buffered records, oldest first.
+func (r *Ring) Records() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshot()
+}
+
+// snapshot copies the buffered records; the caller holds r.mu.
+func (r *Ring) snapshot() [][]byte {
+	n := uint64(len(r.slots))
+	start := uint64(0)
+	if r.next > n {
+		start = r.next - n
+	}
+	out := make([][]byte, 0, r.next-start)
+	for seq := start; seq < r.next; seq++ {
+		out = append(out, append([]byte(nil), r.slots[seq%n]...))
+	}
+	return out
+}
+
+// subscribe registers a live-tail channel and returns the current backlog atomically with it.
+func (r *Ring) subscribe() ([][]byte, chan []byte) {
+	ch := make(chan []byte, 256)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs[ch] = struct{}{}
+	return r.snapshot(), ch
+}
+
+func (r *Ring) unsubscribe(ch chan []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.subs, ch)
+}
+
+// ringFilter selects records by minimum level, namespace and trace id.
+type ringFilter struct {
+	level Level
+	ns    string // trace namespace prefix (api matches api and api.*)
+	id    string // trace id (matches any dotted segment run of the trace)
+	limit int
+}
+
+func parseRingFilter(req *http.Request) ringFilter {
+	q := req.URL.Query()
+	f := ringFilter{level: LevelDebug, ns: q.Get("ns"), id: q.Get("trace")}
+	if s := q.Get("level"); s != "" {
+		f.level = ParseLevel(s)
+	}
+	f.limit, _ = strconv.Atoi(q.Get("n"))
+	return f
+}
+
+func (f ringFilter) match(line []byte) bool {
+	if f.level == LevelDebug && f.ns == "" && f.id == "" {
+		return true
+	}
+	rec := logfmt.Decode(line)
+	if Level(logfmt.Level(rec.Level)) < f.level {
+		return false
+	}
+	if f.ns != "" && rec.Trace != f.ns && !strings.HasPrefix(rec.Trace, f.ns+".") {
+		return false
+	}
+	if f.id != "" {
+		t := "." + rec.Trace + "."
+		if !strings.Contains(t, "."+f.id+".") {
+			return false
+		}
+	}
+	return true
+}
+
+// Handler returns an http.Handler serving the buffer.
+//
+// Query parameters: level (minimum, e.g. WRN), ns (namespace prefix),
+// trace (trace id segment) and n (last n matching records).
+// With Accept: text/event-stream or ?follow=1 the matching backlog is sent
+// followed by new records as Server-Sent Events until the client disconnects;
+// otherwise the matching records are returned as plain logfmt text.
+func (r *Ring) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		f := parseRingFilter(req)
+		if req.URL.Query().Get("follow") != "" || strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+			r.serveSSE(w, req, f)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		for _, line := range f.tail(r.Records()) {
+			bw.Write(line)
+		}
+		bw.Flush()
+	})
+}
+
+// tail filters lines and keeps the last f.limit matches.
+func (f ringFilter) tail(lines [][]byte) [][]byte {
+	out := lines[:0]
+	for _, line := range lines {
+		if f.match(line) {
+			out = append(out, line)
+		}
+	}
+	if f.limit > 0 && len(out) > f.limit {
+		out = out[len(out)-f.limit:]
+	}
+	return out
+}
+
+func (r *Ring) serveSSE(w http.ResponseWriter, req *http.Request, f ringFilter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	backlog, ch := r.subscribe()
+	defer r.unsubscribe(ch)
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	for _, line := range f.tail(backlog) {
+		writeEvent(w, line)
+	}
+	flusher.Flush()
+	tk := time.NewTicker(ringKeepAlive)
+	defer tk.Stop()
+	for {
+		select {
+		case line := <-ch:
+			if f.match(line) {
+				writeEvent(w, line)
+				flusher.Flush()
+			}
+		case <-tk.C:
+			io.WriteString(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent writes one record as an SSE data event (records are single-line).
+func writeEvent(w io.Writer, line []byte) {
+	io.WriteString(w, "data: ")
+	w.Write(bytes.TrimRight(line, "\n"))
+	io.WriteString(w, "\n\n")
+}
@@ -0,0 +1,119 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRingEvict verifies the ring keeps only the last n records, oldest first.
+func TestRingEvict(t *testing.T) {
+	r := NewRing(3)
+	for _, s := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		r.Write([]byte(s))
+	}
+	got := r.Records()
+	if len(got) != 3 || string(got[0]) != "b\n" || string(got[2]) != "d\n" {
+		t.Fatalf("ring contents mismatch: %q", got)
+	}
+	if n := len(NewRing(0).slots); n != 1 {
+		t.Fatalf("NewRing(0) should hold 1 record, got %d", n)
+	}
+}
+
+// TestRingWriteNoAlloc verifies steady-state writes reuse slot buffers.
+func TestRingWriteNoAlloc(t *testing.T) {
+	r := NewRing(4)
+	line := []byte("time=2026-01-01T00:00:00.000 level=INF msg=hello\n")
+	for i := 0; i < 4; i++ {
+		r.Write(line)
+	}
+	if n := testing.AllocsPerRun(100, func() { r.Write(line) }); n != 0 {
+		t.Fatalf("Ring.Write allocates %v times per call", n)
+	}
+}
+
+// TestRingHandlerFilter verifies level, namespace, trace and n filters.
+func TestRingHandlerFilter(t *testing.T) {
+	r := NewRing(16)
+	l := New(r, WithHijack(false), WithLevel(LevelDebug))
+	l.Debug("d0")
+	l.Trace("api").Info("i1")
+	l.Trace("api.v2").Warn("w2")
+	l.Trace("apix").Error("e3")
+	l.Trace("api").Ctx(TraceCtx(context.Background(), "k2m3n4p5")).Error("e4")
+
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	cases := map[string][]string{
+		"":                  {"d0", "i1", "w2", "e3", "e4"},
+		"?level=WRN":        {"w2", "e3", "e4"},
+		"?ns=api":           {"i1", "w2", "e4"},
+		"?trace=k2m3n4p5":   {"e4"},
+		"?ns=api&level=ERR": {"e4"},
+		"?n=2":              {"e3", "e4"},
+	}
+	for q, want := range cases {
+		resp, err := http.Get(srv.URL + q)
+		if err != nil {
+			t.Fatalf("get %q: %v", q, err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		if len(lines) != len(want) {
+			t.Fatalf("%q: want %d lines, got %q", q, len(want), b)
+		}
+		for i, msg := range want {
+			if !strings.HasSuffix(lines[i], "msg="+msg) {
+				t.Fatalf("%q line %d: want msg=%s, got %s", q, i, msg, lines[i])
+			}
+		}
+	}
+}
+
+// TestRingHandlerSSE verifies backlog and live records stream as Server-Sent Events.
+func TestRingHandlerSSE(t *testing.T) {
+	r := NewRing(8)
+	l := New(r, WithHijack(false))
+	l.Error("backlog")
+	l.Info("filtered")
+
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?level=ERR", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type mismatch: %s", ct)
+	}
+	sc := bufio.NewScanner(resp.Body)
+	next := func() string {
+		for sc.Scan() {
+			if line := sc.Text(); strings.HasPrefix(line, "data: ") {
+				return line
+			}
+		}
+		t.Fatalf("stream ended: %v", sc.Err())
+		return ""
+	}
+	if got := next(); !strings.HasSuffix(got, "msg=backlog") {
+		t.Fatalf("backlog event mismatch: %s", got)
+	}
+	l.Info("live-info")
+	l.Error("live")
+	if got := next(); !strings.HasSuffix(got, "msg=live") {
+		t.Fatalf("live event mismatch: %s", got)
+	}
+}
@@ -0,0 +1,142 @@
+package logs
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	sampleSlots       = 1024 // counters per level; keys hash into a fixed table
+	sampleCallersSkip = 3    // frames above the call site: runtime.Callers, allow, Logger/fielder method
+	defSampleInterval = time.Second
+)
+
+// Sampling configures record sampling. Within every Interval, the first First
+// records per level and per message+call site are logged, then every
+// Thereafter-th; the rest are dropped and counted (see Logger.SampledOut).
+type Sampling struct {
+	Interval   time.Duration // budget window (default 1s)
+	First      int           // records allowed per key per interval
+	Thereafter int           // then keep every Thereafter-th record (0 drops the rest)
+	KeepErrors bool          // ERR records bypass sampling
+}
+
+// sampleCounter counts records for one key in the current interval.
+type sampleCounter struct {
+	resetAt atomic.Int64 // unix nanos when the interval ends
+	n       atomic.Uint64
+}
+
+// inc counts one record and returns the count within the current interval.
+func (c *sampleCounter) inc(now, interval int64) uint64 {
+	reset := c.resetAt.Load()
+	if now < reset {
+		return c.n.Add(1)
+	}
+	if c.resetAt.CompareAndSwap(reset, now+interval) {
+		c.n.Store(1)
+		return 1
+	}
+	return c.n.Add(1)
+}
+
+// sampler holds a separate budget table and drop counter for each level.
+// Distinct keys may share a counter when their hashes collide.
+type sampler struct {
+	interval   int64
+	first      uint64
+	thereafter uint64
+	keepErrors bool
+	counts     [4][sampleSlots]sampleCounter
+	dropped    [4]atomic.Uint64
+}
+
+// newSampler builds a sampler from s; it returns nil (no sampling) when
+// neither First nor Thereafter is set.
+func newSampler(s Sampling) *sampler {
+	if s.First <= 0 && s.Thereafter <= 0 {
+		return nil
+	}
+	if s.Interval <= 0 {
+		s.Interval = defSampleInterval
+	}
+	smp := &sampler{interval: int64(s.Interval), keepErrors: s.KeepErrors}
+	if s.First > 0 {
+		smp.first = uint64(s.First)
+	}
+	if s.Thereafter > 0 {
+		smp.thereafter = uint64(s.Thereafter)
+	}
+	return smp
+}
+
+// levelIndex maps DBG/INF/WRN/ERR to 0..3.
+func levelIndex(lv Level) int {
+	i := int(lv-LevelDebug) / 4
+	if i < 0 {
+		return 0
+	}
+	if i > 3 {
+		return 3
+	}
+	return i
+}
+
+// allow reports whether a record at lv with msg from the call site should be logged.
+// It must be called directly from the Logger/fielder logging method; skip is
+// the extra frame count set by fielder.Skip. The call site is resolved like
+// the caller field, so frames of functions marked by Helper are passed over.
+//
+//go:noinline
+func (s *sampler) allow(c *config, lv Level, skip int, msg string) bool {
+	if s.keepErrors && lv >= LevelError {
+		return true
+	}
+	f := callerFrame(sampleCallersSkip + c.skip + skip)
+	// FNV-1a over the call site and msg
+	h := uint64(14695981039346656037) ^ uint64(f.line)
+	for i := 0; i < len(f.file); i++ {
+		h ^= uint64(f.file[i])
+		h *= 1099511628211
+	}
+	for i := 0; i < len(msg); i++ {
+		h ^= uint64(msg[i])
+		h *= 1099511628211
+	}
+	li := levelIndex(lv)
+	n := s.counts[li][h%sampleSlots].inc(c.now().UnixNano(), s.interval)
+	if n <= s.first || s.thereafter > 0 && (n-s.first)%s.thereafter == 0 {
+		return true
+	}
+	s.dropped[li].Add(1)
+	if c.metrics != nil {
+		c.metrics.drop(dropSampling)
+	}
+	return false
+}
+
+// msgOf returns the sampling key message of print-style arguments.
+func msgOf(args []any) string {
+	if len(args) > 0 {
+		if s, ok := args[0].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// Sample derives a Logger with its own sampling budget (shared by Loggers
+// derived from it). A zero Sampling disables sampling for the derived Logger.
+func (l *Logger) Sample(s Sampling) *Logger {
+	c := l.Clone()
+	c.smp = newSampler(s)
+	return c
+}
+
+// SampledOut returns the number of records at lv dropped by this Logger's sampler.
+func (l *Logger) SampledOut(lv Level) uint64 {
+	if l.smp == nil {
+		return 0
+	}
+	return l.smp.dropped[levelIndex(lv)].Load()
+}
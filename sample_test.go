@@ -0,0 +1,111 @@
+package logs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSampling verifies the burst allowance, the 1-in-N tail and the interval reset.
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	clk := &fakeClock{t: time.Date(2026, 5, 9, 10, 0, 0, 0, time.UTC)}
+	l := New(&buf, WithHijack(false), WithClock(clk),
+		WithSampling(Sampling{Interval: time.Second, First: 2, Thereafter: 3}))
+	for i := 0; i < 10; i++ {
+		l.Info("hot")
+	}
+	// kept: 1, 2, then 5 and 8
+	if n := strings.Count(buf.String(), "msg=hot"); n != 4 {
+		t.Fatalf("want 4 records, got %d:\n%s", n, buf.String())
+	}
+	if got := l.SampledOut(LevelInfo); got != 6 {
+		t.Fatalf("SampledOut(INF) = %d, want 6", got)
+	}
+	clk.Advance(time.Second)
+	buf.Reset()
+	l.Info("hot")
+	l.Info("hot")
+	if n := strings.Count(buf.String(), "msg=hot"); n != 2 {
+		t.Fatalf("budget should reset after the interval, got %d", n)
+	}
+}
+
+// TestSamplingKeys verifies budgets are separate per level, message and call site.
+func TestSamplingKeys(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithLevel(LevelDebug), WithSampling(Sampling{First: 1}))
+	for i := 0; i < 3; i++ {
+		l.Info("a")
+		l.Info("a") // second call site
+		l.Warn("a")
+		l.Infof("b %d", i)
+		l.With().Int("i", i).Debug("c")
+	}
+	out := buf.String()
+	if n := strings.Count(out, "level=INF msg=a"); n != 2 {
+		t.Fatalf("want one INF record per call site, got %d:\n%s", n, out)
+	}
+	for _, want := range []string{"level=WRN msg=a", "msg=\"b 0\"", "i=0 msg=c"} {
+		if strings.Count(out, want) != 1 {
+			t.Fatalf("want exactly one %q:\n%s", want, out)
+		}
+	}
+	if l.SampledOut(LevelInfo) != 6 || l.SampledOut(LevelWarn) != 2 || l.SampledOut(LevelDebug) != 2 {
+		t.Fatalf("sampled-out counters mismatch: %d %d %d",
+			l.SampledOut(LevelInfo), l.SampledOut(LevelWarn), l.SampledOut(LevelDebug))
+	}
+}
+
+// sampleHelper logs through a function marked by Helper.
+func sampleHelper(l *Logger) {
+	Helper()
+	l.Info("helped")
+}
+
+// sampleSkip logs with its own frame skipped.
+func sampleSkip(l *Logger) {
+	l.With().Skip(1).Info("skipped")
+}
+
+// TestSamplingCallSite verifies the call site key honors Helper and fielder.Skip,
+// so a shared wrapper still gets a budget per caller.
+func TestSamplingCallSite(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithSampling(Sampling{First: 1}))
+	for i := 0; i < 3; i++ {
+		sampleHelper(l)
+		sampleHelper(l) // second call site
+		sampleSkip(l)
+		sampleSkip(l) // second call site
+	}
+	out := buf.String()
+	for _, want := range []string{"msg=helped", "msg=skipped"} {
+		if n := strings.Count(out, want); n != 2 {
+			t.Fatalf("want one %q per call site, got %d:\n%s", want, n, out)
+		}
+	}
+}
+
+// TestSamplingDerived verifies per-Logger budgets and the ERR bypass.
+func TestSamplingDerived(t *testing.T) {
+	var buf bytes.Buffer
+	root := New(&buf, WithHijack(false))
+	hot := root.Sample(Sampling{First: 1, KeepErrors: true})
+	for i := 0; i < 3; i++ {
+		hot.Trace("loop").Info("tick") // derived Loggers share the budget
+		hot.Error("fail")
+		root.Info("root")
+	}
+	out := buf.String()
+	if strings.Count(out, "msg=tick") != 1 || strings.Count(out, "msg=fail") != 3 || strings.Count(out, "msg=root") != 3 {
+		t.Fatalf("derived sampling mismatch:\n%s", out)
+	}
+	if hot.SampledOut(LevelInfo) != 2 || root.SampledOut(LevelInfo) != 0 {
+		t.Fatalf("counters mismatch: %d %d", hot.SampledOut(LevelInfo), root.SampledOut(LevelInfo))
+	}
+	if off := hot.Sample(Sampling{}); off.smp != nil {
+		t.Fatal("zero Sampling should disable sampling")
+	}
+}
@@ -0,0 +1,196 @@
+package logs
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// Detector finds one kind of sensitive text for WithScrub.
+type Detector struct {
+	hint  func(s string) bool // cheap precheck; nil always runs re
+	re    *regexp.Regexp
+	valid func(m string) bool // rejects false positives; nil accepts all
+	mask  Mask                // set by WithScrub
+}
+
+var (
+	emailRe = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	cardRe  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	ipRe    = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|[0-9A-Fa-f]*:[0-9A-Fa-f]*:[0-9A-Fa-f:.]*`)
+	jwtRe   = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	awsRe   = regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[A-Z0-9]{16}\b`)
+)
+
+// DetectEmail finds email addresses.
+func DetectEmail() Detector {
+	return Detector{hint: func(s string) bool { return strings.IndexByte(s, '@') > 0 }, re: emailRe}
+}
+
+// DetectCard finds payment card numbers of 13 to 19 digits, optionally
+// grouped by spaces or dashes, that pass the Luhn check.
+func DetectCard() Detector {
+	return Detector{hint: hasDigits(13), re: cardRe, valid: luhn}
+}
+
+// DetectIP finds IPv4 and IPv6 addresses.
+func DetectIP() Detector {
+	return Detector{
+		hint: func(s string) bool { return strings.Count(s, ".") >= 3 || strings.Count(s, ":") >= 2 },
+		re:   ipRe,
+		valid: func(m string) bool {
+			_, err := netip.ParseAddr(m)
+			return err == nil
+		},
+	}
+}
+
+// DetectJWT finds JSON Web Tokens.
+func DetectJWT() Detector {
+	return Detector{hint: func(s string) bool { return strings.Contains(s, "eyJ") }, re: jwtRe}
+}
+
+// DetectAWSKey finds AWS access key IDs (AKIA..., ASIA...).
+func DetectAWSKey() Detector {
+	return Detector{hint: hasAWSPrefix, re: awsRe}
+}
+
+// hasAWSPrefix reports whether s may hold an AWS access key ID.
+func hasAWSPrefix(s string) bool {
+	for i := 0; ; i++ {
+		j := strings.IndexByte(s[i:], 'A')
+		if j < 0 || i+j+20 > len(s) {
+			return false
+		}
+		i += j
+		switch s[i : i+4] {
+		case "AKIA", "ASIA", "AGPA", "AIDA", "AROA", "AIPA", "ANPA", "ANVA":
+			return true
+		}
+	}
+}
+
+// DetectRegexp finds matches of expr. It panics if expr does not compile.
+func DetectRegexp(expr string) Detector {
+	return Detector{re: regexp.MustCompile(expr)}
+}
+
+// WithScrub replaces sensitive text found by detectors in messages and field
+// text (strings, errors, Stringers, and string members and elements of
+// objects and arrays) with mask(text); with no detectors, all built-in ones
+// are used. MaskHash pseudonymizes: the same input always maps to the same
+// output, so values stay correlatable. A nil mask means MaskStars.
+// Scrubbing runs the detectors on every message and field text.
+func WithScrub(mask Mask, detectors ...Detector) Option {
+	if mask == nil {
+		mask = maskStars
+	}
+	if len(detectors) == 0 {
+		detectors = []Detector{DetectEmail(), DetectCard(), DetectIP(), DetectJWT(), DetectAWSKey()}
+	}
+	return func(c *config) {
+		if c.redact == nil {
+			c.redact = new(redactor)
+		}
+		for _, d := range detectors {
+			d.mask = mask
+			c.redact.detectors = append(c.redact.detectors, d)
+		}
+	}
+}
+
+// scrubbing reports whether r scrubs text.
+func (r *redactor) scrubbing() bool {
+	return r != nil && len(r.detectors) > 0
+}
+
+// scrubValue returns the scrubbed text of error and fmt.Stringer values,
+// which are written as strings; ok is false for other values, or when r does
+// not scrub.
+func (r *redactor) scrubValue(v any) (s string, ok bool) {
+	if !r.scrubbing() {
+		return "", false
+	}
+	switch v := v.(type) {
+	case fmt.Stringer:
+		return r.scrub(stringerText(v)), true
+	case error:
+		return r.scrub(errorText(v)), true
+	}
+	return "", false
+}
+
+// scrub returns s with detected text masked. It does not allocate when
+// nothing is found.
+func (r *redactor) scrub(s string) string {
+	if !r.scrubbing() {
+		return s
+	}
+	for i := range r.detectors {
+		d := &r.detectors[i]
+		if d.hint != nil && !d.hint(s) {
+			continue
+		}
+		s = d.replace(s)
+	}
+	return s
+}
+
+func (d *Detector) replace(s string) string {
+	locs := d.re.FindAllStringIndex(s, -1)
+	if locs == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range locs {
+		m := s[loc[0]:loc[1]]
+		if m == "" || d.valid != nil && !d.valid(m) {
+			continue
+		}
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(d.mask(m))
+		last = loc[1]
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// hasDigits returns a hint requiring at least n digits.
+func hasDigits(n int) func(string) bool {
+	return func(s string) bool {
+		seen := 0
+		for i := 0; i < len(s); i++ {
+			if '0' <= s[i] && s[i] <= '9' {
+				if seen++; seen == n {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// luhn reports whether the digits of m pass the Luhn checksum.
+func luhn(m string) bool {
+	sum, double := 0, false
+	for i := len(m) - 1; i >= 0; i-- {
+		c := m[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
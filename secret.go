@@ -0,0 +1,71 @@
+package logs
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/zxysilent/logs/internal/textenc"
+)
+
+var secretMask atomic.Pointer[string]
+
+func init() {
+	mask := "***"
+	secretMask.Store(&mask)
+}
+
+// SetSecretMask sets the text Sensitive values render as (default ***).
+func SetSecretMask(mask string) {
+	secretMask.Store(&mask)
+}
+
+// Sensitive holds a value that renders masked wherever it is logged: print
+// and printf (all verbs), fmt.Stringer, Any and encoding/json. The value sits
+// behind a pointer, so even as an unexported struct field, which fmt and
+// encoding/json read through reflection, it is never printed.
+type Sensitive[T any] struct{ v *T }
+
+// NewSensitive wraps v.
+func NewSensitive[T any](v T) Sensitive[T] {
+	return Sensitive[T]{v: &v}
+}
+
+// Secret wraps a credential such as a password or token.
+func Secret(s string) Sensitive[string] {
+	return NewSensitive(s)
+}
+
+// Value returns the wrapped value (the zero value for a zero Sensitive).
+func (s Sensitive[T]) Value() T {
+	if s.v == nil {
+		var zero T
+		return zero
+	}
+	return *s.v
+}
+
+// String returns the mask.
+func (s Sensitive[T]) String() string {
+	return *secretMask.Load()
+}
+
+// Format writes the mask for every verb, quoted for %q.
+func (s Sensitive[T]) Format(f fmt.State, verb rune) {
+	if verb == 'q' {
+		io.WriteString(f, strconv.Quote(s.String()))
+		return
+	}
+	io.WriteString(f, s.String())
+}
+
+// MarshalJSON encodes the mask as a JSON string.
+func (s Sensitive[T]) MarshalJSON() ([]byte, error) {
+	return textenc.PutJSONString(nil, s.String()), nil
+}
+
+// MarshalText returns the mask.
+func (s Sensitive[T]) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
@@ -0,0 +1,58 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type loginForm struct {
+	User     string
+	Password Sensitive[string]
+	pin      Sensitive[int]
+}
+
+// TestSecretPaths verifies secrets render masked on every logging path.
+func TestSecretPaths(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false))
+	pw := Secret("hunter2")
+	form := loginForm{User: "bob", Password: pw, pin: NewSensitive(424242)}
+	l.Info(pw)
+	l.Info("pw ", pw)
+	l.Infof("%v|%+v|%#v|%s|%q|%x|%d", pw, pw, pw, pw, pw, pw, NewSensitive(424242))
+	l.Infof("%v|%+v", form, &form)
+	l.With().Any("form", form).Stringer("pw", pw).Any("pw2", pw).Info("fields")
+	l.Infow("kv", "pw", pw, "form", form)
+	l.Log(LevelInfo, "typed", Any("pw", pw), Stringer("pw2", pw))
+	out := buf.String()
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "424242") {
+		t.Fatalf("secret leaked:\n%s", out)
+	}
+	for _, want := range []string{
+		"msg=***", `msg="pw ***"`, `msg=***|***|***|***|\"***\"|***|***`, `msg="{bob *** {0x`,
+		`form={"User":"bob","Password":"***"} pw=*** pw2="***" msg=fields`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want %q in\n%s", want, out)
+		}
+	}
+	if pw.Value() != "hunter2" || form.pin.Value() != 424242 || (Sensitive[int]{}).Value() != 0 {
+		t.Fatal("Value does not reveal the wrapped value")
+	}
+}
+
+// TestSecretMask verifies SetSecretMask and JSON output.
+func TestSecretMask(t *testing.T) {
+	SetSecretMask("[redacted]")
+	defer SetSecretMask("***")
+	b, err := json.Marshal(map[string]any{"k": Secret("x")})
+	if err != nil || string(b) != `{"k":"[redacted]"}` {
+		t.Fatalf("json = %s, %v", b, err)
+	}
+	if got := fmt.Sprint(Secret("x")); got != "[redacted]" {
+		t.Fatalf("Sprint = %q", got)
+	}
+}
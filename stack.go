@@ -0,0 +1,67 @@
+package logs
+
+import (
+	"runtime"
+	"strconv"
+
+	"github.com/zxysilent/logs/internal/textenc"
+)
+
+const (
+	stackFieldName    = "stack"
+	maxStackDepth     = 64
+	defaultStackDepth = 32
+)
+
+// WithStackLevel attaches a stack field to every record at lv or above,
+// e.g. WithStackLevel(LevelError). Stacks start at the logging call site.
+func WithStackLevel(lv Level) Option {
+	return func(c *config) { c.stackLevel = lv }
+}
+
+// WithStackDepth sets the maximum number of frames in stack fields
+// (default 32, at most 64).
+func WithStackDepth(n int) Option {
+	return func(c *config) {
+		if n < 1 || n > maxStackDepth {
+			panic("illegal logs stack depth")
+		}
+		c.stackDepth = n
+	}
+}
+
+// Stack adds a stack field showing where the record is logged.
+func (s *fielder) Stack() *fielder {
+	if s.attr == nil {
+		return s
+	}
+	// runtime.Callers, putStack, Stack
+	s.cfg.putStack(s.attr, s.cfg.skip+s.depth+3)
+	return s
+}
+
+// putStack writes stack=["func file:line",...] into buf, rendering file paths
+// like putCaller; the value is quoted, as frames contain spaces. skip is the
+// full frame count passed to runtime.Callers.
+func (c *config) putStack(buf *buffer, skip int) {
+	var pcs [maxStackDepth]uintptr
+	depth := c.stackDepth
+	if depth < 1 || depth > maxStackDepth {
+		depth = defaultStackDepth
+	}
+	n := runtime.Callers(skip, pcs[:depth])
+	// Build the JSON apart, so quoting it does not double the record in buf.
+	raw := getb()
+	defer putb(raw)
+	*raw = append(*raw, '[')
+	for i, pc := range pcs[:n] {
+		if i > 0 {
+			*raw = append(*raw, ',')
+		}
+		f := lookupFrame(pc)
+		*raw = append(textenc.PutEscaped(append(*raw, '"'), f.name), ' ')
+		*raw = append(textenc.PutEscaped(*raw, c.framePath(f)), ':')
+		*raw = append(strconv.AppendInt(*raw, int64(f.line), 10), '"')
+	}
+	*buf = c.enc.PutText(textenc.PutKeyRaw(*buf, stackFieldName), append(*raw, ']'))
+}
@@ -0,0 +1,81 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+// stackOf decodes the stack field of a single record, which must parse
+// with the strict logfmt grammar.
+func stackOf(t *testing.T, line string) []string {
+	t.Helper()
+	fields, err := parseStrict(strings.TrimSuffix(line, "\n"))
+	if err != nil {
+		t.Fatalf("bad record %q: %v", line, err)
+	}
+	for _, f := range fields {
+		if f.Key == stackFieldName {
+			var frames []string
+			if err := json.Unmarshal([]byte(f.Val), &frames); err != nil {
+				t.Fatalf("bad stack %q: %v", f.Val, err)
+			}
+			return frames
+		}
+	}
+	return nil
+}
+
+// TestStackLevel verifies automatic stacks start at the call site for every entry point.
+func TestStackLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithLevel(LevelDebug), WithStackLevel(LevelInfo))
+	calls := []func(){
+		func() { l.Error("e") },
+		func() { l.Warnf("w%d", 1) },
+		func() { l.Errorw("kv", "k", 1) },
+		func() { l.With().Str("k", "v").Error("f") },
+		func() { l.Log(LevelError, "typed") },
+	}
+	for i, call := range calls {
+		buf.Reset()
+		call()
+		frames := stackOf(t, buf.String())
+		if len(frames) < 2 || !strings.HasPrefix(frames[0], "github.com/zxysilent/logs.TestStackLevel.func") ||
+			!strings.Contains(frames[0], " /stack_test.go:") {
+			t.Fatalf("call %d: stack does not start at the call site: %q", i, frames)
+		}
+	}
+	buf.Reset()
+	l.Debug("quiet")
+	if strings.Contains(buf.String(), "stack=") {
+		t.Fatalf("stack below the stack level: %s", buf.String())
+	}
+}
+
+// TestStackOnDemand verifies fielder.Stack and the depth limit.
+func TestStackOnDemand(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithStackDepth(1))
+	l.With().Stack().Info("here")
+	frames := stackOf(t, buf.String())
+	if len(frames) != 1 || !strings.HasPrefix(frames[0], "github.com/zxysilent/logs.TestStackOnDemand ") {
+		t.Fatalf("Stack mismatch: %q", frames)
+	}
+	if !strings.Contains(buf.String(), `\"]" msg=here`) {
+		t.Fatalf("stack not written as a field: %s", buf.String())
+	}
+}
+
+// TestStackAllocs verifies stack capture does not allocate.
+func TestStackAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items under the race detector")
+	}
+	l := New(io.Discard, WithHijack(false), WithStackLevel(LevelError))
+	if n := testing.AllocsPerRun(100, func() { l.Error("x") }); n != 0 {
+		t.Fatalf("stack capture allocates %.0f times", n)
+	}
+}
@@ -39,22 +39,22 @@ func (w *stdWriter) Write(p []byte) (int, error) {
 
 // Print logs at info level (stdlib-compatible).
 func (l *Logger) Print(args ...any) {
-	if LevelInfo >= l.cfg.level {
-		l.cfg.print(l.trace, LevelInfo, l.cfg.caller, l.preb(), args...)
+	if LevelInfo >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelInfo, 0, msgOf(args))) {
+		l.cfg.print(nil, l.trace, LevelInfo, l.cfg.caller, 0, l.preb(), args...)
 	}
 }
 
 // Println logs at info level (stdlib-compatible).
 func (l *Logger) Println(args ...any) {
-	if LevelInfo >= l.cfg.level {
-		l.cfg.print(l.trace, LevelInfo, l.cfg.caller, l.preb(), args...)
+	if LevelInfo >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelInfo, 0, msgOf(args))) {
+		l.cfg.print(nil, l.trace, LevelInfo, l.cfg.caller, 0, l.preb(), args...)
 	}
 }
 
 // Printf logs a formatted message at info level (stdlib-compatible).
 func (l *Logger) Printf(format string, args ...any) {
-	if LevelInfo >= l.cfg.level {
-		l.cfg.printf(l.trace, LevelInfo, l.cfg.caller, l.preb(), format, args...)
+	if LevelInfo >= l.cfg.level && (l.smp == nil || l.smp.allow(l.cfg, LevelInfo, 0, format)) {
+		l.cfg.printf(nil, l.trace, LevelInfo, l.cfg.caller, 0, l.preb(), format, args...)
 	}
 }
 
@@ -0,0 +1,191 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+	"unicode/utf8"
+
+	"github.com/zxysilent/logs/internal/logfmt"
+	"github.com/zxysilent/logs/internal/textenc"
+)
+
+// nasty holds the pieces random keys and values are built from.
+var nasty = []string{"a", "Z", "0", ".", " ", "=", `"`, `\`, "\n", "\t", "\x00", "\x7f", "é", "世",
+	"\u0085", "\u2028", "\xff", "{", "}", "[", "]", ",", ":"}
+
+// nastyString is a random string for quick.Check.
+type nastyString string
+
+// Generate implements quick.Generator.
+func (nastyString) Generate(r *rand.Rand, size int) reflect.Value {
+	var sb strings.Builder
+	for n := r.Intn(8); n > 0; n-- {
+		sb.WriteString(nasty[r.Intn(len(nasty))])
+	}
+	return reflect.ValueOf(nastyString(sb.String()))
+}
+
+// parseStrict parses a line with the strict logfmt grammar: bare keys and
+// values are runs of bytes above ' ' other than '=' and '"'; quoted values
+// use Go/JSON escapes. Any other input is an error.
+func parseStrict(line string) ([]logfmt.Field, error) {
+	var fields []logfmt.Field
+	bare := func(i int) int {
+		for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		return i
+	}
+	for i := 0; i < len(line); {
+		if line[i] == ' ' {
+			i++
+			continue
+		}
+		end := bare(i)
+		if end == i || end == len(line) || line[end] != '=' {
+			return nil, errors.New("bad key at " + strconv.Itoa(i))
+		}
+		f := logfmt.Field{Key: line[i:end]}
+		i = end + 1
+		if i < len(line) && line[i] == '"' {
+			j := i + 1
+			for j < len(line) && line[j] != '"' {
+				if line[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(line) {
+				return nil, errors.New("unterminated quote")
+			}
+			v, err := strconv.Unquote(line[i : j+1])
+			if err != nil {
+				return nil, err
+			}
+			f.Val, f.Quoted, i = v, true, j+1
+		} else {
+			end = bare(i)
+			f.Val, i = line[i:end], end
+		}
+		if i < len(line) && line[i] != ' ' {
+			return nil, errors.New("garbage after value at " + strconv.Itoa(i))
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// valid replaces invalid UTF-8 bytes one by one, as the encoder does.
+func valid(s string) string { return string([]rune(s)) }
+
+// TestStrictRoundTrip verifies every strict record parses with a strict
+// logfmt grammar and with the sink decoder, preserving each value.
+func TestStrictRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithStrictLogfmt(true))
+	check := func(k, v, w nastyString) bool {
+		buf.Reset()
+		key, val, val2 := string(k), string(v), string(w)
+		l.With("tr").Str(key, val).Any("any", map[string]string{val: val2}).Raw("raw", []byte(val2)).
+			Strs("strs", []string{val, val2}).Object("obj", ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+			enc.AddString(val, val2)
+			return enc.AddArray("arr", ArrayMarshalerFunc(func(a ArrayEncoder) error {
+				a.AppendString(val)
+				a.AppendAny(map[string]string{val: val2})
+				return nil
+			}))
+		})).Info(val2)
+		line := strings.TrimSuffix(buf.String(), "\n")
+		fields, err := parseStrict(line)
+		if err != nil {
+			t.Logf("strict parse %q: %v", line, err)
+			return false
+		}
+		if got := logfmt.Parse([]byte(line)); !reflect.DeepEqual(stripQuoted(got), stripQuoted(fields)) {
+			t.Logf("decoders disagree on %q:\n%+v\n%+v", line, got, fields)
+			return false
+		}
+		if len(fields) != 10 {
+			t.Logf("field count %d in %q", len(fields), line)
+			return false
+		}
+		for _, f := range fields {
+			if !textenc.ValidKey(f.Key) {
+				t.Logf("invalid key %q in %q", f.Key, line)
+				return false
+			}
+		}
+		anyJSON, _ := json.Marshal(map[string]string{val: val2})
+		var strs []string
+		if json.Unmarshal([]byte(fields[6].Val), &strs) != nil || !reflect.DeepEqual(strs, []string{valid(val), valid(val2)}) {
+			t.Logf("strs mismatch %q in %q", fields[6].Val, line)
+			return false
+		}
+		var arr, wantArr []any
+		elem, _ := json.Marshal(valid(val))
+		json.Unmarshal([]byte(`[`+string(elem)+`,`+string(anyJSON)+`]`), &wantArr)
+		if json.Unmarshal([]byte(fields[8].Val), &arr) != nil || !reflect.DeepEqual(arr, wantArr) {
+			t.Logf("arr mismatch %q in %q", fields[8].Val, line)
+			return false
+		}
+		want := []string{"tr", valid(val), string(anyJSON), valid(val2), valid(val2), valid(val2)}
+		got := []string{fields[2].Val, fields[3].Val, fields[4].Val, fields[5].Val, fields[7].Val, fields[9].Val}
+		if !reflect.DeepEqual(got, want) {
+			t.Logf("values mismatch in %q:\n got %q\nwant %q", line, got, want)
+			return false
+		}
+		return utf8.RuneCountInString(fields[3].Key) == max1(utf8.RuneCountInString(valid(key))) &&
+			strings.HasPrefix(fields[7].Key, "obj.") && fields[8].Key == "obj.arr"
+	}
+	if err := quick.Check(check, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// stripQuoted drops the Quoted flag, which the decoders set differently for JSON values.
+func stripQuoted(fs []logfmt.Field) []logfmt.Field {
+	out := make([]logfmt.Field, len(fs))
+	for i, f := range fs {
+		out[i] = logfmt.Field{Key: f.Key, Val: f.Val}
+	}
+	return out
+}
+
+// max1 returns n, or 1 for an empty key (sanitized to "_").
+func max1(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// TestStrictNestedAny verifies JSON nested in arrays is quoted once, as part
+// of the array, and that strict mode only applies to its own Logger.
+func TestStrictNestedAny(t *testing.T) {
+	var strict, plain bytes.Buffer
+	arr := ArrayMarshalerFunc(func(a ArrayEncoder) error {
+		a.AppendAny(map[string]int{"a b": 1})
+		a.AppendString("x")
+		return a.AppendObject(ObjectMarshalerFunc(func(o ObjectEncoder) error {
+			o.AddAny("k", []string{"v w"})
+			return nil
+		}))
+	})
+	New(&strict, WithHijack(false), WithStrictLogfmt(true)).Log(LevelInfo, "m", Array("arr", arr))
+	New(&plain, WithHijack(false)).Log(LevelInfo, "m", Array("arr", arr))
+	const want = `[{"a b":1},"x",{"k":["v w"]}]`
+	fields, err := parseStrict(strings.TrimSuffix(strict.String(), "\n"))
+	if err != nil || len(fields) != 4 || fields[2].Val != want {
+		t.Fatalf("strict arr = %+v, %v", fields, err)
+	}
+	if !strings.Contains(plain.String(), " arr="+want+" ") {
+		t.Fatalf("plain Logger affected by strict mode: %s", plain.String())
+	}
+}
@@ -0,0 +1,51 @@
+package logs
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSugared verifies key/value encoding, typed fast paths and the JSON fallback.
+func TestSugared(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithLevel(LevelDebug))
+	l.Infow("login", "user", "bob smith", "count", 3, "ok", true, "ratio", 0.5,
+		"err", errors.New("x y"), "wait", time.Second, "tags", []string{"a"}, "none", nil)
+	want := `level=INF user="bob smith" count=3 ok=true ratio=0.5 err="x y" wait=1s tags=["a"] none=null msg=login` + "\n"
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Fatalf("Infow mismatch:\n got: %s\nwant: %s", got, want)
+	}
+	buf.Reset()
+	l.With("db").Str("k", "v").Errorw("query", "rows", 2)
+	l.Debugw("plain")
+	l.Warnw("w", 7, "a", "dangling")
+	out := buf.String()
+	for _, want := range []string{
+		"level=ERR trace=db k=v rows=2 msg=query\n",
+		"level=DBG msg=plain\n",
+		`level=WRN !BADKEY=7 a=dangling msg=w` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	buf.Reset()
+	l.Infow("odd", "a", 1, "b")
+	if !strings.Contains(buf.String(), "a=1 !BADKEY=b msg=odd") {
+		t.Fatalf("dangling key mismatch: %s", buf.String())
+	}
+}
+
+// TestSugaredCaller verifies *w methods report the call site.
+func TestSugaredCaller(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, WithHijack(false), WithCaller(true))
+	l.Infow("a", "k", 1)
+	l.With().Warnw("b")
+	if n := strings.Count(buf.String(), "sugar_test.go:"); n != 2 {
+		t.Fatalf("caller mismatch:\n%s", buf.String())
+	}
+}